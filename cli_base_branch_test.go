@@ -0,0 +1,91 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// setupRepoWithRemote creates a repository whose origin is a bare repository
+// in which main has moved on by one commit since it was last fetched. The
+// checkout is left on a stale feature branch.
+func setupRepoWithRemote(t *testing.T, baseBranch string) (string, string) {
+	t.Helper()
+	repoDir, remoteDir := setupGitRepo(t, `commands:
+  - name: "goversion"
+    targets:
+    - path: "version.txt"
+      pattern: "^version: (?P<version>.*)$"
+    git:
+      create_branch: true
+      branch_name: "repver/{{version}}"
+      base_branch: "`+baseBranch+`"
+      fetch: true
+      commit: true
+      commit_message: "Update version to {{version}}"
+      remote: "origin"
+`, gitRepoOptions{remote: true})
+	runCommand(t, repoDir, "git", "checkout", "-b", "feature")
+
+	// Move main on the remote from a second clone
+	otherDir := filepath.Join(t.TempDir(), "other")
+	runCommand(t, repoDir, "git", "clone", remoteDir, otherDir)
+	runCommand(t, otherDir, "git", "config", "user.name", "Repver Test")
+	runCommand(t, otherDir, "git", "config", "user.email", "repver@example.com")
+	if err := os.WriteFile(filepath.Join(otherDir, "README.md"), []byte("# Project\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	runCommand(t, otherDir, "git", "add", ".")
+	runCommand(t, otherDir, "git", "commit", "-m", "Add README")
+	runCommand(t, otherDir, "git", "push", "origin", "main")
+
+	return repoDir, strings.TrimSpace(runCommand(t, otherDir, "git", "rev-parse", "HEAD"))
+}
+
+func TestBaseBranchFromRemote(t *testing.T) {
+	binary := buildBinary(t)
+	repoDir, remoteHead := setupRepoWithRemote(t, "origin/main")
+
+	cmd := exec.Command(binary, "--command=goversion", "--param-version=1.3.0", "--no-color")
+	cmd.Dir = repoDir
+	if output, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("repver failed: %v\n%s", err, output)
+	}
+
+	if parent := strings.TrimSpace(runCommand(t, repoDir, "git", "rev-parse", "repver/1.3.0^")); parent != remoteHead {
+		t.Errorf("expected the branch to start from the fetched origin/main %s, got %s", remoteHead, parent)
+	}
+	if upstream, err := exec.Command("git", "-C", repoDir, "rev-parse", "--abbrev-ref", "repver/1.3.0@{upstream}").Output(); err == nil {
+		t.Errorf("expected the branch not to track the base, got %s", upstream)
+	}
+}
+
+func TestBaseBranchDiverged(t *testing.T) {
+	binary := buildBinary(t)
+	repoDir, _ := setupRepoWithRemote(t, "main")
+
+	// Add a local commit to main so it diverges from the remote after fetching
+	runCommand(t, repoDir, "git", "checkout", "main")
+	if err := os.WriteFile(filepath.Join(repoDir, "CHANGELOG.md"), []byte("# Changes\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	runCommand(t, repoDir, "git", "add", ".")
+	runCommand(t, repoDir, "git", "commit", "-m", "Add changelog")
+	runCommand(t, repoDir, "git", "checkout", "feature")
+
+	cmd := exec.Command(binary, "--command=goversion", "--param-version=1.3.0", "--no-color")
+	cmd.Dir = repoDir
+	output, err := cmd.CombinedOutput()
+	exitErr, ok := err.(*exec.ExitError)
+	if !ok || exitErr.ExitCode() != 206 {
+		t.Fatalf("expected exit code 206, got %v\n%s", err, output)
+	}
+	if !strings.Contains(string(output), "has diverged from 'origin/main'") {
+		t.Errorf("expected the divergence to be explained, got:\n%s", output)
+	}
+	if branch := strings.TrimSpace(runCommand(t, repoDir, "git", "rev-parse", "--abbrev-ref", "HEAD")); branch != "feature" {
+		t.Errorf("expected to stay on feature, got %s", branch)
+	}
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCheckModeDetectsDrift(t *testing.T) {
+	binary := buildBinary(t)
+	tmpDir := t.TempDir()
+
+	repverContent := `commands:
+  - name: "goversion"
+    params:
+    - name: "version"
+      pattern: "^(?P<major>\\d+)\\.(?P<minor>\\d+)\\.(?P<patch>\\d+)$"
+    targets:
+    - path: "go.mod"
+      pattern: "^go (?P<version>.*)$"
+      transform: "{{major}}.{{minor}}"
+    - path: "build.yml"
+      pattern: "^go-version: '(?P<version>.*)'$"
+`
+	if err := os.WriteFile(filepath.Join(tmpDir, ".repver"), []byte(repverContent), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(tmpDir, "go.mod"), []byte("go 1.22\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(tmpDir, "build.yml"), []byte("go-version: '1.22.3'\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, args := range [][]string{{"check"}, {"--check"}} {
+		cmd := exec.Command(binary, args...)
+		cmd.Dir = tmpDir
+		if output, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("repver %v returned error for agreeing targets: %v\n%s", args, err, output)
+		}
+	}
+
+	if err := os.WriteFile(filepath.Join(tmpDir, "build.yml"), []byte("go-version: '1.23.0'\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cmd := exec.Command(binary, "check")
+	cmd.Dir = tmpDir
+	output, err := cmd.CombinedOutput()
+	exitErr, ok := err.(*exec.ExitError)
+	if !ok {
+		t.Fatalf("expected ExitError, got %v\n%s", err, output)
+	}
+	if exitErr.ExitCode() != 112 {
+		t.Errorf("expected exit code 112, got %d", exitErr.ExitCode())
+	}
+	if !strings.Contains(string(output), "'minor'") || !strings.Contains(string(output), "build.yml:1") {
+		t.Errorf("expected drift report for minor in build.yml, got:\n%s", output)
+	}
+}
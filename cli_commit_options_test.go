@@ -0,0 +1,86 @@
+package main
+
+import (
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+// setupCommitOptionsRepo creates a repository whose command commits with a
+// body, trailers, an author override and sign-off
+func setupCommitOptionsRepo(t *testing.T) string {
+	t.Helper()
+	repoDir, _ := setupGitRepo(t, `commands:
+  - name: "goversion"
+    targets:
+    - path: "version.txt"
+      pattern: "^version: (?P<version>.*)$"
+    git:
+      commit: true
+      commit_message: "Update version to {{version}}"
+      commit_body: |
+        Updated from {{old.version}}.
+
+        Generated by repver.
+      trailers:
+        Co-authored-by: "Release Bot <bot@example.com>"
+        Release: "v{{version}}"
+      author: "Release Bot <bot@example.com>"
+      signoff: true
+`, gitRepoOptions{})
+	return repoDir
+}
+
+func TestCommitOptions(t *testing.T) {
+	binary := buildBinary(t)
+	tmpDir := setupCommitOptionsRepo(t)
+
+	cmd := exec.Command(binary, "--command=goversion", "--param-version=1.3.0", "--no-color")
+	cmd.Dir = tmpDir
+	if output, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("repver failed: %v\n%s", err, output)
+	}
+
+	log := runCommand(t, tmpDir, "git", "log", "-1", "--format=%an <%ae>%n%cn <%ce>%n%B")
+	expected := `Release Bot <bot@example.com>
+Repver Test <repver@example.com>
+Update version to 1.3.0
+
+Updated from 1.2.3.
+
+Generated by repver.
+
+Co-authored-by: Release Bot <bot@example.com>
+Release: v1.3.0
+Signed-off-by: Repver Test <repver@example.com>`
+	if strings.TrimSpace(log) != expected {
+		t.Errorf("unexpected commit:\n%s\nwant:\n%s", log, expected)
+	}
+}
+
+func TestCommitOptionsDryRun(t *testing.T) {
+	binary := buildBinary(t)
+	tmpDir := setupCommitOptionsRepo(t)
+
+	cmd := exec.Command(binary, "--command=goversion", "--param-version=1.3.0", "--dry-run", "--no-color")
+	cmd.Dir = tmpDir
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("repver failed: %v\n%s", err, output)
+	}
+
+	for _, expected := range []string{
+		`Would commit changes with message: "Update version to 1.3.0"`,
+		"  Updated from 1.2.3.",
+		"  Release: v1.3.0",
+		"Would set the commit author to 'Release Bot <bot@example.com>'",
+		"Would add a Signed-off-by trailer for the committer",
+	} {
+		if !strings.Contains(string(output), expected) {
+			t.Errorf("expected output to contain %q, got:\n%s", expected, output)
+		}
+	}
+	if log := runCommand(t, tmpDir, "git", "log", "--oneline"); strings.Count(log, "\n") != 1 {
+		t.Errorf("expected no commit in dry run mode, got:\n%s", log)
+	}
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPatchOutWritesApplicablePatch(t *testing.T) {
+	binary := buildBinary(t)
+	tmpDir := t.TempDir()
+
+	runCommand(t, tmpDir, "git", "init", "-b", "main")
+
+	repverContent := `commands:
+  - name: "goversion"
+    targets:
+    - path: "version.txt"
+      pattern: "^version: (?P<version>.*)$"
+`
+	if err := os.WriteFile(filepath.Join(tmpDir, ".repver"), []byte(repverContent), 0644); err != nil {
+		t.Fatal(err)
+	}
+	original := "name: example\nversion: 1.2.3\n"
+	if err := os.WriteFile(filepath.Join(tmpDir, "version.txt"), []byte(original), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cmd := exec.Command(binary, "--command=goversion", "--param-version=1.3.0", "--patch-out=changes.patch", "--diff", "--no-color")
+	cmd.Dir = tmpDir
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("repver returned error: %v\n%s", err, output)
+	}
+	if !strings.Contains(string(output), "+version: 1.3.0") {
+		t.Errorf("expected unified diff in output, got:\n%s", output)
+	}
+
+	content, err := os.ReadFile(filepath.Join(tmpDir, "version.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(content) != original {
+		t.Fatalf("expected target to be untouched, got %q", content)
+	}
+
+	runCommand(t, tmpDir, "git", "apply", "changes.patch")
+
+	content, err = os.ReadFile(filepath.Join(tmpDir, "version.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(content) != "name: example\nversion: 1.3.0\n" {
+		t.Errorf("expected patch to apply the change, got %q", content)
+	}
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// setupDirtyRepo creates a repository with a committed version file, a
+// modified tracked file and an untracked file
+func setupDirtyRepo(t *testing.T, dirtyTree string) string {
+	t.Helper()
+	repoDir, _ := setupGitRepo(t, `commands:
+  - name: "goversion"
+    targets:
+    - path: "version.txt"
+      pattern: "^version: (?P<version>.*)$"
+    git:
+      create_branch: true
+      branch_name: "repver/{{version}}"
+      commit: true
+      commit_message: "Update version to {{version}}"
+      return_to_original_branch: true
+      dirty_tree: "`+dirtyTree+`"
+`, gitRepoOptions{files: map[string]string{"README.md": "# Project\n"}})
+
+	if err := os.WriteFile(filepath.Join(repoDir, "README.md"), []byte("# Project\n\nWork in progress\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(repoDir, "notes.txt"), []byte("notes\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return repoDir
+}
+
+// assertLocalChangesKept checks that the local changes made by setupDirtyRepo
+// are still in the checkout
+func assertLocalChangesKept(t *testing.T, dir string) {
+	t.Helper()
+	readme, err := os.ReadFile(filepath.Join(dir, "README.md"))
+	if err != nil || string(readme) != "# Project\n\nWork in progress\n" {
+		t.Errorf("expected the README.md change to be kept, got %q (%v)", readme, err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "notes.txt")); err != nil {
+		t.Errorf("expected the untracked file to be kept: %v", err)
+	}
+}
+
+func TestDirtyTreeFail(t *testing.T) {
+	binary := buildBinary(t)
+	tmpDir := setupDirtyRepo(t, "fail")
+
+	cmd := exec.Command(binary, "--command=goversion", "--param-version=1.3.0", "--no-color")
+	cmd.Dir = tmpDir
+	output, err := cmd.CombinedOutput()
+	exitErr, ok := err.(*exec.ExitError)
+	if !ok || exitErr.ExitCode() != 107 {
+		t.Fatalf("expected exit code 107, got %v\n%s", err, output)
+	}
+}
+
+func TestDirtyTreeStash(t *testing.T) {
+	binary := buildBinary(t)
+	tmpDir := setupDirtyRepo(t, "stash")
+
+	cmd := exec.Command(binary, "--command=goversion", "--param-version=1.3.0", "--no-color")
+	cmd.Dir = tmpDir
+	if output, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("repver failed: %v\n%s", err, output)
+	}
+
+	if branch := strings.TrimSpace(runCommand(t, tmpDir, "git", "rev-parse", "--abbrev-ref", "HEAD")); branch != "main" {
+		t.Errorf("expected to be back on main, got %s", branch)
+	}
+	if content := runCommand(t, tmpDir, "git", "show", "repver/1.3.0:version.txt"); content != "version: 1.3.0\n" {
+		t.Errorf("expected the branch to hold the update, got %q", content)
+	}
+	if stashes := runCommand(t, tmpDir, "git", "stash", "list"); stashes != "" {
+		t.Errorf("expected the stash to be popped, got %q", stashes)
+	}
+	assertLocalChangesKept(t, tmpDir)
+}
+
+func TestDirtyTreeWorktree(t *testing.T) {
+	binary := buildBinary(t)
+	tmpDir := setupDirtyRepo(t, "worktree")
+
+	cmd := exec.Command(binary, "--command=goversion", "--param-version=1.3.0", "--no-color")
+	cmd.Dir = tmpDir
+	if output, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("repver failed: %v\n%s", err, output)
+	}
+
+	if branch := strings.TrimSpace(runCommand(t, tmpDir, "git", "rev-parse", "--abbrev-ref", "HEAD")); branch != "main" {
+		t.Errorf("expected the checkout to stay on main, got %s", branch)
+	}
+	if content := runCommand(t, tmpDir, "git", "show", "repver/1.3.0:version.txt"); content != "version: 1.3.0\n" {
+		t.Errorf("expected the branch to hold the update, got %q", content)
+	}
+	if content, _ := os.ReadFile(filepath.Join(tmpDir, "version.txt")); string(content) != "version: 1.2.3\n" {
+		t.Errorf("expected the checkout to be untouched, got %q", content)
+	}
+	if worktrees := strings.Count(runCommand(t, tmpDir, "git", "worktree", "list"), "\n"); worktrees != 1 {
+		t.Errorf("expected the temporary worktree to be removed, found %d worktrees", worktrees)
+	}
+	assertLocalChangesKept(t, tmpDir)
+}
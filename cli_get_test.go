@@ -0,0 +1,115 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+func TestGetModePrintsCurrentValues(t *testing.T) {
+	binary := buildBinary(t)
+	tmpDir := t.TempDir()
+
+	repverContent := `commands:
+  - name: "goversion"
+    params:
+    - name: "version"
+      pattern: "^(?P<major>\\d+)\\.(?P<minor>\\d+)\\.(?P<patch>\\d+)$"
+    targets:
+    - path: "go.mod"
+      pattern: "^go (?P<gomod>.*)$"
+      transforms:
+        gomod: "{{major}}.{{minor}}"
+    - path: "build.yml"
+      pattern: "^go-version: '(?P<version>.*)'$"
+`
+	if err := os.WriteFile(filepath.Join(tmpDir, ".repver"), []byte(repverContent), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(tmpDir, "go.mod"), []byte("module example\n\ngo 1.22\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(tmpDir, "build.yml"), []byte("go-version: '1.22.3'\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		format   string
+		expected string
+	}{
+		{"text", "go.mod:3 gomod=1.22\nbuild.yml:1 version=1.22.3\n"},
+		{"env", "major=1\nminor=22\npatch=3\nversion=1.22.3\n"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.format, func(t *testing.T) {
+			cmd := exec.Command(binary, "get", "--command=goversion", "--format="+tc.format, "--no-color")
+			cmd.Dir = tmpDir
+			output, err := cmd.Output()
+			if err != nil {
+				t.Fatalf("repver get returned error: %v\n%s", err, output)
+			}
+			if string(output) != tc.expected {
+				t.Errorf("expected:\n%s\ngot:\n%s", tc.expected, output)
+			}
+		})
+	}
+
+	t.Run("json", func(t *testing.T) {
+		cmd := exec.Command(binary, "get", "--command=goversion", "--format=json")
+		cmd.Dir = tmpDir
+		output, err := cmd.Output()
+		if err != nil {
+			t.Fatalf("repver get returned error: %v\n%s", err, output)
+		}
+
+		var got getOutput
+		if err := json.Unmarshal(output, &got); err != nil {
+			t.Fatalf("invalid JSON output: %v\n%s", err, output)
+		}
+		if len(got.Targets) != 2 || got.Targets[0].Line != 3 || got.Targets[0].Values["gomod"] != "1.22" {
+			t.Errorf("unexpected targets: %+v", got.Targets)
+		}
+		if got.Params["version"] != "1.22.3" || got.Params["minor"] != "22" {
+			t.Errorf("unexpected params: %+v", got.Params)
+		}
+	})
+}
+
+func TestGetModeEnvQuotesValues(t *testing.T) {
+	binary := buildBinary(t)
+	tmpDir := t.TempDir()
+
+	repverContent := `commands:
+  - name: "image"
+    targets:
+    - path: "Dockerfile"
+      pattern: "^FROM (?P<image>.*)$"
+`
+	if err := os.WriteFile(filepath.Join(tmpDir, ".repver"), []byte(repverContent), 0644); err != nil {
+		t.Fatal(err)
+	}
+	value := `golang:1.22 # it's $HOME`
+	if err := os.WriteFile(filepath.Join(tmpDir, "Dockerfile"), []byte("FROM "+value+"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cmd := exec.Command(binary, "get", "--command=image", "--format=env", "--no-color")
+	cmd.Dir = tmpDir
+	output, err := cmd.Output()
+	if err != nil {
+		t.Fatalf("repver get returned error: %v\n%s", err, output)
+	}
+
+	// Evaluating the output must give back the value unchanged
+	shell := exec.Command("sh", "-c", string(output)+`printf %s "$image"`)
+	evaluated, err := shell.Output()
+	if err != nil {
+		t.Fatalf("evaluating %q failed: %v", output, err)
+	}
+	if string(evaluated) != value {
+		t.Errorf("expected %q after eval, got %q from %q", value, evaluated, output)
+	}
+}
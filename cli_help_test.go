@@ -0,0 +1,93 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeHelpFixture(t *testing.T, dir string) {
+	t.Helper()
+
+	repverContent := `commands:
+  - name: "goversion"
+    description: "Update the Go version"
+    params:
+    - name: "version"
+      pattern: "^(?P<major>\\d+)\\.(?P<minor>\\d+)\\.(?P<patch>\\d+)$"
+      description: "The Go version to build with"
+      example: "1.26.0"
+    targets:
+    - path: "go.mod"
+      description: "Go module file"
+      pattern: "^go (?P<version>.*)$"
+      transform: "{{major}}.{{minor}}"
+    git:
+      create_branch: true
+      branch_name: "repver/go-v{{version}}"
+      commit: true
+      commit_message: "Update Go version to {{version}}"
+      push: true
+      remote: "origin"
+      pull_request: "GITHUB_CLI"
+`
+	if err := os.WriteFile(filepath.Join(dir, ".repver"), []byte(repverContent), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "go.mod"), []byte("go 1.25\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestHelpShowsCommandDetails(t *testing.T) {
+	binary := buildBinary(t)
+	tmpDir := t.TempDir()
+	writeHelpFixture(t, tmpDir)
+
+	for _, args := range [][]string{
+		{"help", "goversion"},
+		{"--command=goversion", "--help"},
+	} {
+		cmd := exec.Command(binary, args...)
+		cmd.Dir = tmpDir
+		output, err := cmd.CombinedOutput()
+		if err != nil {
+			t.Fatalf("repver %v returned error: %v\n%s", args, err, output)
+		}
+
+		got := string(output)
+		for _, want := range []string{
+			"Update the Go version",
+			"--param-version",
+			"The Go version to build with",
+			"Example:     1.26.0",
+			"Go module file",
+			"git checkout -b repver/go-v{{version}}",
+			"git push origin repver/go-v{{version}}",
+			"gh pr create --fill",
+		} {
+			if !strings.Contains(got, want) {
+				t.Errorf("repver %v: expected output to contain %q, got:\n%s", args, want, got)
+			}
+		}
+	}
+}
+
+func TestHelpUnknownCommand(t *testing.T) {
+	binary := buildBinary(t)
+	tmpDir := t.TempDir()
+	writeHelpFixture(t, tmpDir)
+
+	cmd := exec.Command(binary, "help", "missing")
+	cmd.Dir = tmpDir
+	err := cmd.Run()
+	exitErr, ok := err.(*exec.ExitError)
+	if !ok {
+		t.Fatalf("expected ExitError, got %v", err)
+	}
+	if exitErr.ExitCode() != 104 {
+		t.Errorf("expected exit code 104, got %d", exitErr.ExitCode())
+	}
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+// setupHooksRepo creates a repository whose command runs the given hooks and
+// commits on a new branch
+func setupHooksRepo(t *testing.T, hooks string) string {
+	t.Helper()
+	repoDir, _ := setupGitRepo(t, `commands:
+  - name: "goversion"
+    targets:
+    - path: "version.txt"
+      pattern: "^version: (?P<version>.*)$"
+    hooks:
+`+hooks+`
+    git:
+      create_branch: true
+      branch_name: "repver/{{version}}"
+      commit: true
+      commit_message: "Update version to {{version}}"
+      return_to_original_branch: true
+`, gitRepoOptions{files: map[string]string{"go.sum": "checksums for 1.2.3\n"}})
+	return repoDir
+}
+
+func TestHooksChangesAreCommitted(t *testing.T) {
+	binary := buildBinary(t)
+	tmpDir := setupHooksRepo(t, `      pre_plan:
+        - "echo checksums for $REPVER_VERSION > go.sum"
+      post_apply:
+        - "mkdir -p gen && cp version.txt gen/{{old.version}}-to-{{version}}.txt"`)
+
+	cmd := exec.Command(binary, "--command=goversion", "--param-version=1.3.0", "--no-color")
+	cmd.Dir = tmpDir
+	if output, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("repver failed: %v\n%s", err, output)
+	}
+
+	files := runCommand(t, tmpDir, "git", "show", "--name-only", "--format=", "repver/1.3.0")
+	if strings.Fields(files)[0] != "gen/1.2.3-to-1.3.0.txt" || !strings.Contains(files, "go.sum") || !strings.Contains(files, "version.txt") {
+		t.Errorf("expected the target and the files changed by hooks to be committed, got:\n%s", files)
+	}
+	if status := runCommand(t, tmpDir, "git", "status", "--porcelain"); status != "" {
+		t.Errorf("expected a clean workspace, got:\n%s", status)
+	}
+}
+
+func TestHookFailureRollsBack(t *testing.T) {
+	binary := buildBinary(t)
+	tmpDir := setupHooksRepo(t, `      post_apply:
+        - "echo checksums for $REPVER_VERSION > go.sum && touch new.txt"
+      pre_commit:
+        - "echo tidy failed; exit 1"`)
+
+	cmd := exec.Command(binary, "--command=goversion", "--param-version=1.3.0", "--no-color")
+	cmd.Dir = tmpDir
+	output, err := cmd.CombinedOutput()
+	exitErr, ok := err.(*exec.ExitError)
+	if !ok || exitErr.ExitCode() != 211 {
+		t.Fatalf("expected exit code 211, got %v\n%s", err, output)
+	}
+	if !strings.Contains(string(output), "tidy failed") {
+		t.Errorf("expected the hook output, got:\n%s", output)
+	}
+
+	if status := runCommand(t, tmpDir, "git", "status", "--porcelain"); status != "" {
+		t.Errorf("expected the workspace to be restored, got:\n%s", status)
+	}
+	if branch := strings.TrimSpace(runCommand(t, tmpDir, "git", "branch", "--show-current")); branch != "main" {
+		t.Errorf("expected to be back on main, got %s", branch)
+	}
+	if branches := runCommand(t, tmpDir, "git", "branch", "--list", "repver/*"); branches != "" {
+		t.Errorf("expected the branch to be deleted, got %s", branches)
+	}
+}
+
+func TestHooksPlanAndApplyRunPrePlan(t *testing.T) {
+	binary := buildBinary(t)
+	tmpDir := setupHooksRepo(t, `      pre_plan:
+        - "echo generated for $REPVER_VERSION > gen.txt"`)
+
+	cmd := exec.Command(binary, "plan", "--command=goversion", "--param-version=1.3.0", "--out=plan.json", "--no-color")
+	cmd.Dir = tmpDir
+	if output, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("repver plan failed: %v\n%s", err, output)
+	}
+	if status := runCommand(t, tmpDir, "git", "status", "--porcelain"); status != "?? plan.json\n" {
+		t.Errorf("expected plan to leave only the plan file, got:\n%s", status)
+	}
+
+	cmd = exec.Command(binary, "apply", "plan.json", "--no-color")
+	cmd.Dir = tmpDir
+	if output, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("repver apply failed: %v\n%s", err, output)
+	}
+
+	files := runCommand(t, tmpDir, "git", "show", "--name-only", "--format=", "repver/1.3.0")
+	if !strings.Contains(files, "gen.txt") || !strings.Contains(files, "version.txt") || strings.Contains(files, "plan.json") {
+		t.Errorf("expected the target and the file changed by the pre_plan hook to be committed, got:\n%s", files)
+	}
+	if status := runCommand(t, tmpDir, "git", "status", "--porcelain"); status != "?? plan.json\n" {
+		t.Errorf("expected only the plan file to remain, got:\n%s", status)
+	}
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestOutputJSONReportsRun(t *testing.T) {
+	binary := buildBinary(t)
+	tmpDir := t.TempDir()
+
+	runCommand(t, tmpDir, "git", "init", "-b", "main")
+	runCommand(t, tmpDir, "git", "config", "user.name", "Repver Test")
+	runCommand(t, tmpDir, "git", "config", "user.email", "repver@example.com")
+
+	repverContent := `commands:
+  - name: "goversion"
+    params:
+    - name: "version"
+      pattern: "^(?P<major>\\d+)\\.(?P<minor>\\d+)\\.(?P<patch>\\d+)$"
+    targets:
+    - path: "version.txt"
+      pattern: "^version: (?P<version>.*)$"
+    git:
+      create_branch: true
+      branch_name: "release-{{version}}"
+      commit: true
+      commit_message: "Update version to {{version}}"
+`
+	if err := os.WriteFile(filepath.Join(tmpDir, ".repver"), []byte(repverContent), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(tmpDir, "version.txt"), []byte("version: 1.2.3\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	runCommand(t, tmpDir, "git", "add", ".")
+	runCommand(t, tmpDir, "git", "commit", "-m", "Initial commit")
+
+	cmd := exec.Command(binary, "--command=goversion", "--param-version=1.3.0", "--dry-run", "--output=json")
+	cmd.Dir = tmpDir
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		t.Fatalf("repver returned error: %v\n%s", err, stderr.String())
+	}
+
+	var result runReport
+	if err := json.Unmarshal(stdout.Bytes(), &result); err != nil {
+		t.Fatalf("stdout is not a JSON report: %v\n%s", err, stdout.String())
+	}
+	if result.Status != statusSuccess || result.ErrorCode != 0 {
+		t.Errorf("expected success, got status %q code %d", result.Status, result.ErrorCode)
+	}
+	if result.Params["version"] != "1.3.0" || result.Groups["minor"] != "3" {
+		t.Errorf("unexpected params %v and groups %v", result.Params, result.Groups)
+	}
+	if len(result.Plans) != 1 || len(result.Plans[0].Changes) != 1 || result.Plans[0].Changes[0].NewLine != "version: 1.3.0" {
+		t.Errorf("unexpected plans: %+v", result.Plans)
+	}
+	if result.Git.Branch != "release-1.3.0" || result.Git.CommitMessage != "Update version to 1.3.0" {
+		t.Errorf("unexpected git report: %+v", result.Git)
+	}
+	if len(result.Git.Steps) != 2 || result.Git.Steps[0].Performed {
+		t.Errorf("expected two planned git steps, got %+v", result.Git.Steps)
+	}
+	if !strings.Contains(stderr.String(), "FILE CHANGES") {
+		t.Errorf("expected human-readable output on stderr, got:\n%s", stderr.String())
+	}
+}
+
+func TestOutputJSONReportsError(t *testing.T) {
+	binary := buildBinary(t)
+	tmpDir := t.TempDir()
+
+	cmd := exec.Command(binary, "--command=goversion", "--output=json")
+	cmd.Dir = tmpDir
+	var stdout bytes.Buffer
+	cmd.Stdout = &stdout
+	err := cmd.Run()
+	exitErr, ok := err.(*exec.ExitError)
+	if !ok {
+		t.Fatalf("expected ExitError, got %v", err)
+	}
+	if exitErr.ExitCode() != 100 {
+		t.Errorf("expected exit code 100, got %d", exitErr.ExitCode())
+	}
+
+	var result runReport
+	if err := json.Unmarshal(stdout.Bytes(), &result); err != nil {
+		t.Fatalf("stdout is not a JSON report: %v\n%s", err, stdout.String())
+	}
+	if result.Status != statusError || result.ErrorCode != 100 || result.Error == "" {
+		t.Errorf("unexpected error report: %+v", result)
+	}
+}
+
+func TestOutputJSONAfterOtherFlags(t *testing.T) {
+	binary := buildBinary(t)
+	tmpDir := t.TempDir()
+
+	// Every static flag is pre-parsed, so --output after them still applies
+	// to errors reported before the full parse
+	cmd := exec.Command(binary, "--command=goversion", "--allow-downgrade", "--no-input", "--diff", "--diff-context=1", "--patch-out=out.patch", "--output=json")
+	cmd.Dir = tmpDir
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	err := cmd.Run()
+	exitErr, ok := err.(*exec.ExitError)
+	if !ok || exitErr.ExitCode() != 100 {
+		t.Fatalf("expected exit code 100, got %v", err)
+	}
+	if strings.Contains(stderr.String(), "flag provided but not defined") {
+		t.Errorf("expected no pre-parse errors, got:\n%s", stderr.String())
+	}
+
+	var result runReport
+	if err := json.Unmarshal(stdout.Bytes(), &result); err != nil {
+		t.Fatalf("stdout is not a JSON report: %v\n%s", err, stdout.String())
+	}
+	if result.ErrorCode != 100 {
+		t.Errorf("unexpected error report: %+v", result)
+	}
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPlanAndApply(t *testing.T) {
+	binary := buildBinary(t)
+	tmpDir := t.TempDir()
+
+	runCommand(t, tmpDir, "git", "init", "-b", "main")
+	runCommand(t, tmpDir, "git", "config", "user.name", "Repver Test")
+	runCommand(t, tmpDir, "git", "config", "user.email", "repver@example.com")
+
+	repverContent := `commands:
+  - name: "goversion"
+    targets:
+    - path: "version.txt"
+      pattern: "^version: (?P<version>.*)$"
+    git:
+      create_branch: true
+      branch_name: "release-{{version}}"
+      commit: true
+      commit_message: "Update version from {{old.version}} to {{version}}"
+`
+	if err := os.WriteFile(filepath.Join(tmpDir, ".repver"), []byte(repverContent), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(tmpDir, "version.txt"), []byte("version: 1.2.3\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	runCommand(t, tmpDir, "git", "add", ".")
+	runCommand(t, tmpDir, "git", "commit", "-m", "Initial commit")
+
+	cmd := exec.Command(binary, "plan", "--command=goversion", "--param-version=1.3.0", "--out=plan.json")
+	cmd.Dir = tmpDir
+	if output, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("repver plan returned error: %v\n%s", err, output)
+	}
+
+	content, err := os.ReadFile(filepath.Join(tmpDir, "version.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(content) != "version: 1.2.3\n" {
+		t.Fatalf("expected plan to leave the target untouched, got %q", content)
+	}
+
+	cmd = exec.Command(binary, "apply", "plan.json")
+	cmd.Dir = tmpDir
+	if output, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("repver apply returned error: %v\n%s", err, output)
+	}
+
+	content, err = os.ReadFile(filepath.Join(tmpDir, "version.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(content) != "version: 1.3.0\n" {
+		t.Errorf("expected apply to write the planned change, got %q", content)
+	}
+
+	branch := strings.TrimSpace(runCommand(t, tmpDir, "git", "rev-parse", "--abbrev-ref", "HEAD"))
+	if branch != "release-1.3.0" {
+		t.Errorf("expected branch release-1.3.0, got %q", branch)
+	}
+	message := strings.TrimSpace(runCommand(t, tmpDir, "git", "log", "-1", "--format=%s"))
+	if message != "Update version from 1.2.3 to 1.3.0" {
+		t.Errorf("unexpected commit message %q", message)
+	}
+}
+
+func TestApplyRefusesChangedTargets(t *testing.T) {
+	binary := buildBinary(t)
+	tmpDir := t.TempDir()
+
+	repverContent := `commands:
+  - name: "goversion"
+    targets:
+    - path: "version.txt"
+      pattern: "^version: (?P<version>.*)$"
+`
+	if err := os.WriteFile(filepath.Join(tmpDir, ".repver"), []byte(repverContent), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(tmpDir, "version.txt"), []byte("version: 1.2.3\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cmd := exec.Command(binary, "plan", "--command=goversion", "--param-version=1.3.0", "--out=plan.json")
+	cmd.Dir = tmpDir
+	if output, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("repver plan returned error: %v\n%s", err, output)
+	}
+
+	if err := os.WriteFile(filepath.Join(tmpDir, "version.txt"), []byte("version: 1.2.4\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cmd = exec.Command(binary, "apply", "plan.json")
+	cmd.Dir = tmpDir
+	err := cmd.Run()
+	exitErr, ok := err.(*exec.ExitError)
+	if !ok {
+		t.Fatalf("expected ExitError, got %v", err)
+	}
+	if exitErr.ExitCode() != 121 {
+		t.Errorf("expected exit code 121, got %d", exitErr.ExitCode())
+	}
+
+	content, err := os.ReadFile(filepath.Join(tmpDir, "version.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(content) != "version: 1.2.4\n" {
+		t.Errorf("expected target to be left alone, got %q", content)
+	}
+}
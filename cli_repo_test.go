@@ -0,0 +1,54 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// gitRepoOptions adjusts the repository created by setupGitRepo
+type gitRepoOptions struct {
+	// files are committed along with .repver and version.txt, keyed by path
+	files map[string]string
+	// remote adds a bare repository as origin and pushes main to it
+	remote bool
+}
+
+// setupGitRepo creates a repository on main whose first commit holds the
+// .repver configuration, a version.txt with "version: 1.2.3" and opts.files.
+// It returns the repository directory and, with opts.remote, the directory of
+// the bare origin repository.
+func setupGitRepo(t *testing.T, repverYAML string, opts gitRepoOptions) (string, string) {
+	t.Helper()
+	root := t.TempDir()
+	repoDir := filepath.Join(root, "repo")
+	remoteDir := ""
+
+	runCommand(t, root, "git", "init", "-b", "main", repoDir)
+	runCommand(t, repoDir, "git", "config", "user.name", "Repver Test")
+	runCommand(t, repoDir, "git", "config", "user.email", "repver@example.com")
+	if opts.remote {
+		remoteDir = filepath.Join(root, "remote.git")
+		runCommand(t, root, "git", "init", "--bare", "-b", "main", remoteDir)
+		runCommand(t, repoDir, "git", "remote", "add", "origin", remoteDir)
+	}
+
+	files := map[string]string{
+		".repver":     repverYAML,
+		"version.txt": "version: 1.2.3\n",
+	}
+	for name, content := range opts.files {
+		files[name] = content
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(repoDir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	runCommand(t, repoDir, "git", "add", ".")
+	runCommand(t, repoDir, "git", "commit", "-m", "Initial commit")
+	if opts.remote {
+		runCommand(t, repoDir, "git", "push", "-u", "origin", "main")
+	}
+	return repoDir, remoteDir
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+func TestSyncModePropagatesSourceValue(t *testing.T) {
+	binary := buildBinary(t)
+	tmpDir := t.TempDir()
+
+	repverContent := `commands:
+  - name: "goversion"
+    source: ".go-version"
+    params:
+    - name: "version"
+      pattern: "^(?P<major>\\d+)\\.(?P<minor>\\d+)\\.(?P<patch>\\d+)$"
+    targets:
+    - path: ".go-version"
+      pattern: "^(?P<version>.*)$"
+    - path: "go.mod"
+      pattern: "^go (?P<gomod>.*)$"
+      transforms:
+        gomod: "{{major}}.{{minor}}"
+  - name: "nosource"
+    targets:
+    - path: "go.mod"
+      pattern: "^go (?P<version>.*)$"
+`
+	if err := os.WriteFile(filepath.Join(tmpDir, ".repver"), []byte(repverContent), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(tmpDir, ".go-version"), []byte("1.23.4\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(tmpDir, "go.mod"), []byte("module example\n\ngo 1.22\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cmd := exec.Command(binary, "sync", "--command=goversion")
+	cmd.Dir = tmpDir
+	if output, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("repver sync returned error: %v\n%s", err, output)
+	}
+
+	content, err := os.ReadFile(filepath.Join(tmpDir, "go.mod"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(content) != "module example\n\ngo 1.23\n" {
+		t.Errorf("expected go.mod to be synced from source, got %q", content)
+	}
+
+	cmd = exec.Command(binary, "sync", "--command=nosource")
+	cmd.Dir = tmpDir
+	err = cmd.Run()
+	exitErr, ok := err.(*exec.ExitError)
+	if !ok {
+		t.Fatalf("expected ExitError, got %v", err)
+	}
+	if exitErr.ExitCode() != 114 {
+		t.Errorf("expected exit code 114, got %d", exitErr.ExitCode())
+	}
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// setupTagRepo creates a repository whose origin is a bare repository and
+// whose command commits, tags and pushes the tag
+func setupTagRepo(t *testing.T) (string, string) {
+	t.Helper()
+	return setupGitRepo(t, `commands:
+  - name: "release"
+    targets:
+    - path: "version.txt"
+      pattern: "^version: (?P<version>.*)$"
+    git:
+      commit: true
+      commit_message: "Release {{version}}"
+      remote: "origin"
+      tag: true
+      tag_name: "v{{version}}"
+      tag_message: "Version {{version}}"
+      push_tags: true
+`, gitRepoOptions{remote: true})
+}
+
+func TestTagCreatedAndPushed(t *testing.T) {
+	binary := buildBinary(t)
+	repoDir, remoteDir := setupTagRepo(t)
+
+	cmd := exec.Command(binary, "--command=release", "--param-version=1.3.0", "--no-color")
+	cmd.Dir = repoDir
+	if output, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("repver failed: %v\n%s", err, output)
+	}
+
+	tag := runCommand(t, repoDir, "git", "for-each-ref", "--format=%(objecttype) %(contents:subject)", "refs/tags/v1.3.0")
+	if strings.TrimSpace(tag) != "tag Version 1.3.0" {
+		t.Errorf("expected an annotated tag with the message, got %q", tag)
+	}
+	head := strings.TrimSpace(runCommand(t, repoDir, "git", "rev-parse", "HEAD"))
+	if remote := strings.TrimSpace(runCommand(t, remoteDir, "git", "rev-parse", "v1.3.0^{commit}")); remote != head {
+		t.Errorf("expected the remote tag to point at %s, got %s", head, remote)
+	}
+
+	// The tag now exists, so the same release is refused before any changes
+	if err := os.WriteFile(filepath.Join(repoDir, "version.txt"), []byte("version: 1.2.3\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	runCommand(t, repoDir, "git", "commit", "-am", "Revert version")
+	runCommand(t, repoDir, "git", "tag", "-d", "v1.3.0")
+
+	cmd = exec.Command(binary, "--command=release", "--param-version=1.3.0", "--no-color")
+	cmd.Dir = repoDir
+	output, err := cmd.CombinedOutput()
+	exitErr, ok := err.(*exec.ExitError)
+	if !ok || exitErr.ExitCode() != 210 {
+		t.Fatalf("expected exit code 210, got %v\n%s", err, output)
+	}
+	if !strings.Contains(string(output), "already exists on remote 'origin'") {
+		t.Errorf("expected the remote tag to be reported, got:\n%s", output)
+	}
+	if status := runCommand(t, repoDir, "git", "status", "--porcelain"); status != "" {
+		t.Errorf("expected no changes to the workspace, got:\n%s", status)
+	}
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// setupVerifyRepo creates a repository with a bare remote whose command runs
+// the given verify commands before committing and pushing
+func setupVerifyRepo(t *testing.T, verify string) string {
+	t.Helper()
+	repoDir, _ := setupGitRepo(t, `commands:
+  - name: "goversion"
+    targets:
+    - path: "version.txt"
+      pattern: "^version: (?P<version>.*)$"
+    verify:
+`+verify+`
+    git:
+      create_branch: true
+      branch_name: "repver/{{version}}"
+      commit: true
+      commit_message: "Update version to {{version}}"
+      push: true
+      remote: "origin"
+      return_to_original_branch: true
+`, gitRepoOptions{remote: true})
+	return repoDir
+}
+
+func TestVerifyPassesAndPushes(t *testing.T) {
+	binary := buildBinary(t)
+	tmpDir := setupVerifyRepo(t, `      - "grep -q 'version: {{version}}' version.txt"`)
+
+	cmd := exec.Command(binary, "--command=goversion", "--param-version=1.3.0", "--no-color")
+	cmd.Dir = tmpDir
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("repver failed: %v\n%s", err, output)
+	}
+	if !strings.Contains(string(output), "Running verify: grep -q 'version: 1.3.0' version.txt") {
+		t.Errorf("expected the verify command to run, got:\n%s", output)
+	}
+	if remote := runCommand(t, tmpDir, "git", "ls-remote", "--heads", "origin", "repver/1.3.0"); remote == "" {
+		t.Error("expected the verified branch to be pushed")
+	}
+}
+
+func TestVerifyFailureRollsBack(t *testing.T) {
+	binary := buildBinary(t)
+	tmpDir := setupVerifyRepo(t, `      - "for i in $(seq 1 30); do echo line $i; done; exit 1"`)
+
+	cmd := exec.Command(binary, "--command=goversion", "--param-version=1.3.0", "--no-color")
+	cmd.Dir = tmpDir
+	output, err := cmd.CombinedOutput()
+	exitErr, ok := err.(*exec.ExitError)
+	if !ok || exitErr.ExitCode() != 212 {
+		t.Fatalf("expected exit code 212, got %v\n%s", err, output)
+	}
+	if !strings.Contains(string(output), "Verification failed") || !strings.Contains(string(output), "showing the last 20 lines") {
+		t.Errorf("expected the error with the last lines of output, got:\n%s", output)
+	}
+
+	content, err := os.ReadFile(filepath.Join(tmpDir, "version.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(content) != "version: 1.2.3\n" {
+		t.Errorf("expected version.txt to be restored, got %q", content)
+	}
+	if branch := strings.TrimSpace(runCommand(t, tmpDir, "git", "branch", "--show-current")); branch != "main" {
+		t.Errorf("expected to be back on main, got %s", branch)
+	}
+	if branches := runCommand(t, tmpDir, "git", "branch", "--list", "repver/*"); branches != "" {
+		t.Errorf("expected the branch to be deleted, got %s", branches)
+	}
+	if remote := runCommand(t, tmpDir, "git", "ls-remote", "--heads", "origin", "repver/*"); remote != "" {
+		t.Errorf("expected nothing to be pushed, got %s", remote)
+	}
+}
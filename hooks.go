@@ -0,0 +1,238 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/UnitVectorY-Labs/repver/internal/color"
+	"github.com/UnitVectorY-Labs/repver/internal/git"
+	"github.com/UnitVectorY-Labs/repver/internal/repver"
+)
+
+// outputTailLines is the number of lines of a failed command's output shown in the error
+const outputTailLines = 20
+
+// hookRunner runs the hooks and verify commands of a command and keeps track
+// of the files the hooks change so they can be committed, or restored if the
+// run fails. A nil hookRunner runs nothing.
+type hookRunner struct {
+	// commands holds the rendered commands of each stage, with the verify
+	// commands under "verify"
+	commands map[string][]string
+	// timeout is the time limit for each command; 0 means no limit
+	timeout time.Duration
+	// env holds the variables added to the environment of every command
+	env []string
+	// changed holds the files changed by the hooks so far
+	changed []git.ChangedFile
+}
+
+// newHookRunner returns a hookRunner for the command with the params and
+// groups as environment variables. The commands of each stage are added once
+// rendered.
+func newHookRunner(hooks *repver.RepverHooks, commandName string, params map[string]string, groups map[string]string) *hookRunner {
+	return &hookRunner{
+		commands: map[string][]string{},
+		timeout:  hooks.CommandTimeout(),
+		env:      hookEnv(commandName, params, groups),
+	}
+}
+
+// hookEnv returns the environment variables describing the run: REPVER_COMMAND
+// and a REPVER_<NAME> variable for each param and group, with the name in upper
+// case. Params take precedence over groups of the same name.
+func hookEnv(commandName string, params map[string]string, groups map[string]string) []string {
+	values := map[string]string{}
+	for name, value := range groups {
+		values[envName(name)] = value
+	}
+	for name, value := range params {
+		values[envName(name)] = value
+	}
+
+	env := []string{"REPVER_COMMAND=" + commandName}
+	for name, value := range values {
+		env = append(env, name+"="+value)
+	}
+	sort.Strings(env[1:])
+	return env
+}
+
+// envName returns the environment variable name for a param or group
+func envName(name string) string {
+	return "REPVER_" + strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' {
+			return r - 'a' + 'A'
+		}
+		if (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') {
+			return r
+		}
+		return '_'
+	}, name)
+}
+
+// has checks if the stage has any commands
+func (r *hookRunner) has(stage string) bool {
+	return r != nil && len(r.commands[stage]) > 0
+}
+
+// preview prints the commands the stage would run in dry run mode
+func (r *hookRunner) preview(stage string) {
+	if !r.has(stage) {
+		return
+	}
+	for _, command := range r.commands[stage] {
+		fmt.Println(color.Yellowf("[DRYRUN] Would run %s hook: %s", stage, command))
+	}
+}
+
+// run runs the commands of the stage in dir, stopping at the first one that
+// fails. With a client, the files the commands changed are then detected with
+// git status and recorded, apart from the paths in known, such as the target
+// files, whose changes are already expected.
+func (r *hookRunner) run(ctx context.Context, stage string, dir string, client git.Client, gitOptions *repver.RepverGit, known []string) error {
+	if !r.has(stage) {
+		return nil
+	}
+
+	for _, command := range r.commands[stage] {
+		// Process: Run hook command
+		fmt.Println(color.Cyanf("Running %s hook: %s", stage, command))
+		output, err := runShell(ctx, dir, command, r.env, r.timeout)
+		if err != nil {
+			if ctx.Err() != nil {
+				return newExitError(130, "Interrupted").causedBy(err)
+			}
+			return newExitError(211, fmt.Sprintf("Hook %s failed: %s (%v)", stage, command, err), outputTail(output))
+		}
+	}
+
+	if client == nil {
+		return nil
+	}
+
+	// Process: Detect files changed by the hook
+	stepCtx, cancel := stepContext(ctx, gitOptions, "query")
+	files, err := client.ChangedFiles(stepCtx)
+	cancel()
+	if err != nil {
+		return stepError(ctx, 518, "Internal error failed to detect files changed by hook", err)
+	}
+	for _, file := range files {
+		if slices.Contains(known, file.Path) || slices.ContainsFunc(r.changed, func(c git.ChangedFile) bool { return c.Path == file.Path }) {
+			continue
+		}
+		fmt.Println(color.Cyanf("File changed by %s hook: %s", stage, file.Path))
+		r.changed = append(r.changed, file)
+	}
+	return nil
+}
+
+// verify runs the verify commands in dir, stopping at the first one that
+// fails. Unlike hooks, files the commands change are not committed.
+func (r *hookRunner) verify(ctx context.Context, dir string) error {
+	if !r.has("verify") {
+		return nil
+	}
+
+	for _, command := range r.commands["verify"] {
+		// Process: Run verify command
+		fmt.Println(color.Cyanf("Running verify: %s", command))
+		output, err := runShell(ctx, dir, command, r.env, r.timeout)
+		if err != nil {
+			if ctx.Err() != nil {
+				return newExitError(130, "Interrupted").causedBy(err)
+			}
+			return newExitError(212, fmt.Sprintf("Verification failed: %s (%v)", command, err), outputTail(output))
+		}
+	}
+	return nil
+}
+
+// paths returns the paths of the files changed by the hooks
+func (r *hookRunner) paths() []string {
+	if r == nil {
+		return nil
+	}
+	paths := make([]string, 0, len(r.changed))
+	for _, file := range r.changed {
+		paths = append(paths, file.Path)
+	}
+	return paths
+}
+
+// restore discards the changes the hooks made: tracked files are restored
+// from HEAD and new files are removed. Paths are relative to dir.
+func (r *hookRunner) restore(ctx context.Context, client git.Client, dir string) error {
+	if r == nil || len(r.changed) == 0 {
+		return nil
+	}
+
+	var tracked []string
+	var errs []error
+	for _, file := range r.changed {
+		if !file.Untracked {
+			tracked = append(tracked, file.Path)
+			continue
+		}
+		if err := os.Remove(filepath.Join(dir, file.Path)); err != nil && !os.IsNotExist(err) {
+			errs = append(errs, err)
+		}
+	}
+	if len(tracked) > 0 {
+		if _, err := client.RestoreFiles(ctx, tracked); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if err := errors.Join(errs...); err != nil {
+		return err
+	}
+
+	fmt.Fprintln(os.Stderr, color.Yellowf("Restored files changed by hooks: %s", strings.Join(r.paths(), ", ")))
+	r.changed = nil
+	return nil
+}
+
+// runShell runs a command with sh in dir, with env added to the environment
+// and stopped after timeout unless it is 0. The combined output is printed as
+// it is produced and also returned.
+func runShell(ctx context.Context, dir string, command string, env []string, timeout time.Duration) (string, error) {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	var output bytes.Buffer
+	cmd := exec.CommandContext(ctx, "sh", "-c", command)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), env...)
+	cmd.Stdout = io.MultiWriter(os.Stdout, &output)
+	cmd.Stderr = io.MultiWriter(os.Stderr, &output)
+	cmd.WaitDelay = time.Second
+
+	err := cmd.Run()
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		err = fmt.Errorf("timed out after %s", timeout)
+	}
+	return output.String(), err
+}
+
+// outputTail returns the last lines of a command's output for an error message
+func outputTail(output string) string {
+	lines := strings.Split(strings.TrimRight(output, "\n"), "\n")
+	if len(lines) > outputTailLines {
+		lines = append([]string{fmt.Sprintf("... (showing the last %d lines of output)", outputTailLines)}, lines[len(lines)-outputTailLines:]...)
+	}
+	return strings.Join(lines, "\n")
+}
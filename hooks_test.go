@@ -0,0 +1,61 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHookEnv(t *testing.T) {
+	env := hookEnv("goversion", map[string]string{"version": "1.23.0"}, map[string]string{"major": "1", "version": "ignored", "pre-release": "rc1"})
+	expected := []string{
+		"REPVER_COMMAND=goversion",
+		"REPVER_MAJOR=1",
+		"REPVER_PRE_RELEASE=rc1",
+		"REPVER_VERSION=1.23.0",
+	}
+	if !reflect.DeepEqual(env, expected) {
+		t.Errorf("unexpected environment:\n got: %v\nwant: %v", env, expected)
+	}
+}
+
+func TestRunShell(t *testing.T) {
+	dir := t.TempDir()
+	output, err := runShell(context.Background(), dir, `echo "$REPVER_VERSION" > version.txt && echo done`, []string{"REPVER_VERSION=1.23.0"}, 0)
+	if err != nil {
+		t.Fatalf("runShell returned error: %v", err)
+	}
+	if output != "done\n" {
+		t.Errorf("expected the output to be captured, got %q", output)
+	}
+	if content, _ := os.ReadFile(filepath.Join(dir, "version.txt")); string(content) != "1.23.0\n" {
+		t.Errorf("expected the command to run in dir with the environment, got %q", content)
+	}
+
+	if _, err := runShell(context.Background(), dir, "exit 3", nil, 0); err == nil || !strings.Contains(err.Error(), "exit status 3") {
+		t.Errorf("expected exit status 3, got %v", err)
+	}
+	if _, err := runShell(context.Background(), dir, "sleep 5", nil, 50*time.Millisecond); err == nil || !strings.Contains(err.Error(), "timed out") {
+		t.Errorf("expected a timeout, got %v", err)
+	}
+}
+
+func TestOutputTail(t *testing.T) {
+	var lines []string
+	for i := 1; i <= 30; i++ {
+		lines = append(lines, strconv.Itoa(i))
+	}
+
+	tail := strings.Split(outputTail(strings.Join(lines, "\n")+"\n"), "\n")
+	if len(tail) != outputTailLines+1 || tail[1] != "11" || tail[len(tail)-1] != "30" {
+		t.Errorf("expected a note and the last %d lines, got %v", outputTailLines, tail)
+	}
+	if got := outputTail("one\ntwo\n"); got != "one\ntwo" {
+		t.Errorf("expected short output unchanged, got %q", got)
+	}
+}
@@ -0,0 +1,146 @@
+package git
+
+import (
+	"context"
+	"os"
+)
+
+// CommitOptions controls how AddAndCommitFiles creates the commit
+type CommitOptions struct {
+	// Sign signs the commit
+	Sign bool
+	// SignFormat sets gpg.format for the commit (openpgp or ssh); empty uses the configured format
+	SignFormat string
+	// Author overrides the commit author, in the form "Name <email>"
+	Author string
+	// Signoff adds a Signed-off-by trailer for the committer
+	Signoff bool
+}
+
+// TagOptions controls how CreateTag creates an annotated tag
+type TagOptions struct {
+	// Message is the message of the tag
+	Message string
+	// Sign signs the tag
+	Sign bool
+	// SignFormat sets gpg.format for the tag (openpgp or ssh); empty uses the configured format
+	SignFormat string
+}
+
+// ChangedFile is a path with uncommitted changes reported by ChangedFiles
+type ChangedFile struct {
+	// Path is relative to the root of the repository
+	Path string
+	// Untracked is set for a new file that git does not track yet
+	Untracked bool
+}
+
+// Client performs the git and gh operations used by repver. ExecClient runs
+// the real commands and FakeClient records calls for tests. Every operation
+// stops when its context is canceled or its deadline passes.
+type Client interface {
+	// IsGitRoot checks if the current working directory is the root of a Git repository.
+	IsGitRoot(ctx context.Context) (bool, error)
+	// BranchExists checks if a local branch with the given name exists.
+	BranchExists(ctx context.Context, branchName string) (bool, error)
+	// GetCurrentBranch retrieves the name of the current branch.
+	GetCurrentBranch(ctx context.Context) (string, error)
+	// SwitchToBranch switches to the specified branch.
+	SwitchToBranch(ctx context.Context, branchName string) (string, error)
+	// CheckGitClean checks that there are no uncommitted changes outside the ignored paths.
+	CheckGitClean(ctx context.Context, ignore ...string) error
+	// ChangedFiles lists the paths with uncommitted changes, including untracked files.
+	ChangedFiles(ctx context.Context) ([]ChangedFile, error)
+	// CreateAndSwitchBranch creates a new branch at startPoint, or HEAD if empty, and switches to it.
+	CreateAndSwitchBranch(ctx context.Context, branchName string, startPoint string) (string, error)
+	// AddAndCommitFiles adds files to the staging area and commits them with a message.
+	AddAndCommitFiles(ctx context.Context, fileNames []string, commitMessage string, options CommitOptions) (string, error)
+	// PushChanges pushes the branch to the remote, with --force-with-lease if force is set.
+	PushChanges(ctx context.Context, remote string, branch string, force bool) (string, error)
+	// DeleteLocalBranch deletes a local branch.
+	DeleteLocalBranch(ctx context.Context, branchName string) (string, error)
+	// GetShortSHA retrieves the abbreviated commit hash of HEAD.
+	GetShortSHA(ctx context.Context) (string, error)
+	// GetHeadSHA retrieves the full commit hash of HEAD.
+	GetHeadSHA(ctx context.Context) (string, error)
+	// GetUserName retrieves the configured Git user name.
+	GetUserName(ctx context.Context) (string, error)
+	// GetRemoteURL retrieves the URL of the specified remote.
+	GetRemoteURL(ctx context.Context, remote string) (string, error)
+	// RestoreFiles discards staged and unstaged changes to the files, restoring them from HEAD.
+	RestoreFiles(ctx context.Context, fileNames []string) (string, error)
+	// CreateGitHubPullRequest creates a pull request using the GitHub CLI against
+	// the base branch, or the repository's default branch if base is empty.
+	CreateGitHubPullRequest(ctx context.Context, base string) (string, error)
+	// GetGitHubPullRequestURL returns the URL of the open pull request for the
+	// branch using the GitHub CLI, or an empty string if there is none.
+	GetGitHubPullRequestURL(ctx context.Context, branch string) (string, error)
+	// ResetAndSwitchBranch resets a branch to startPoint, or HEAD if empty,
+	// creating it if needed, and switches to it.
+	ResetAndSwitchBranch(ctx context.Context, branchName string, startPoint string) (string, error)
+	// ResetBranch points an existing branch that is not checked out at the commit.
+	ResetBranch(ctx context.Context, branchName string, commit string) (string, error)
+	// ResolveRef returns the full commit hash a ref points to.
+	ResolveRef(ctx context.Context, ref string) (string, error)
+	// Fetch fetches a branch from the remote, updating its remote-tracking branch.
+	Fetch(ctx context.Context, remote string, branch string) (string, error)
+	// ValidRefName checks if a full ref name, such as refs/heads/main, is well formed.
+	ValidRefName(ctx context.Context, ref string) (bool, error)
+	// RefExists checks if a ref, such as a branch or remote-tracking branch, names a commit.
+	RefExists(ctx context.Context, ref string) (bool, error)
+	// RemoteTagExists checks if the remote has a tag with the given name.
+	RemoteTagExists(ctx context.Context, remote string, tagName string) (bool, error)
+	// CreateTag creates an annotated tag at HEAD.
+	CreateTag(ctx context.Context, tagName string, options TagOptions) (string, error)
+	// PushTag pushes a tag to the remote.
+	PushTag(ctx context.Context, remote string, tagName string) (string, error)
+	// DeleteTag deletes a local tag.
+	DeleteTag(ctx context.Context, tagName string) (string, error)
+	// CountDivergence counts the commits on local that are not on upstream and
+	// the commits on upstream that are not on local.
+	CountDivergence(ctx context.Context, local string, upstream string) (ahead int, behind int, err error)
+	// StashChanges stashes all uncommitted changes, including untracked files.
+	StashChanges(ctx context.Context, message string) (string, error)
+	// PopStash restores the most recently stashed changes and drops the stash.
+	PopStash(ctx context.Context) (string, error)
+	// AddWorktree checks out HEAD in a new worktree at path with a detached HEAD.
+	AddWorktree(ctx context.Context, path string) (string, error)
+	// RemoveWorktree removes the worktree at path, discarding any changes in it.
+	RemoveWorktree(ctx context.Context, path string) (string, error)
+	// InDir returns a Client that runs its commands in dir, such as a worktree,
+	// instead of the current working directory.
+	InDir(dir string) Client
+}
+
+// ExecClient implements Client by running the git and gh executables in the
+// current working directory
+type ExecClient struct {
+	// DisablePrompts stops git and gh from prompting for credentials or other
+	// input, so a command that needs input fails instead of waiting forever
+	DisablePrompts bool
+	// Dir is the directory commands run in; empty for the current working directory
+	Dir string
+}
+
+var _ Client = ExecClient{}
+
+// NewExecClient returns a Client that runs the git and gh executables.
+// Prompts are disabled unless the run is interactive.
+func NewExecClient(interactive bool) Client {
+	return ExecClient{DisablePrompts: !interactive}
+}
+
+// InDir returns a copy of the client that runs its commands in dir
+func (c ExecClient) InDir(dir string) Client {
+	c.Dir = dir
+	return c
+}
+
+// environment returns the environment for git and gh commands
+func (c ExecClient) environment() []string {
+	env := os.Environ()
+	if c.DisablePrompts {
+		env = append(env, "GIT_TERMINAL_PROMPT=0", "GCM_INTERACTIVE=never", "GH_PROMPT_DISABLED=1")
+	}
+	return env
+}
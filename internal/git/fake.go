@@ -0,0 +1,506 @@
+package git
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// Call records a single method invocation on a FakeClient
+type Call struct {
+	Method string
+	Args   []string
+	// Dir is the directory the call ran in; empty for the main checkout
+	Dir string
+}
+
+// FakeCommit records a commit made through a FakeClient
+type FakeCommit struct {
+	Branch  string
+	Files   []string
+	Message string
+	Options CommitOptions
+}
+
+// FakeClient is an in-memory Client for tests. It keeps a minimal model of a
+// repository (the current branch, local branches, commits and pushes), records
+// every call, and returns the error configured in Errors for a method instead
+// of performing it. Clients returned by InDir share the repository with the
+// client that created them but have their own current branch.
+type FakeClient struct {
+	// NotGitRoot makes IsGitRoot report that the directory is not the repository root
+	NotGitRoot bool
+	// Dirty lists the paths reported as uncommitted changes by CheckGitClean and ChangedFiles
+	Dirty []string
+	// Untracked lists the paths in Dirty that ChangedFiles reports as untracked
+	Untracked []string
+	// Branch is the current branch
+	Branch string
+	// Branches holds the names of the local branches
+	Branches map[string]bool
+	// RemoteBranches holds the names of the remote-tracking branches, such as "origin/main"
+	RemoteBranches map[string]bool
+	// Ahead and Behind are the commit counts reported by CountDivergence
+	Ahead, Behind int
+	// HeadSHA is the full commit hash reported for HEAD
+	HeadSHA string
+	// UserName is the configured Git user name
+	UserName string
+	// Remotes maps remote names to their URLs
+	Remotes map[string]string
+	// PullRequestURL is printed by CreateGitHubPullRequest
+	PullRequestURL string
+	// Files maps paths to their committed content, written by AddWorktree
+	Files map[string]string
+	// Errors maps a method name to the error it returns
+	Errors map[string]error
+	// OnCall is called with the method name at the start of every call, for
+	// example to cancel the context in the middle of a run
+	OnCall func(method string)
+
+	// Calls records every method called, in order
+	Calls []Call
+	// Commits records the commits made
+	Commits []FakeCommit
+	// Pushes records each push as "<remote>/<branch>"
+	Pushes []string
+	// ForcePushes records each push made with --force-with-lease as "<remote>/<branch>"
+	ForcePushes []string
+	// OpenPullRequests maps branch names to the URL of their open pull request
+	OpenPullRequests map[string]string
+	// Resets records each branch reset by ResetBranch as "<branch>@<commit>"
+	Resets []string
+	// PullRequests counts the pull requests created
+	PullRequests int
+	// PullRequestBase is the base branch of the last pull request created
+	PullRequestBase string
+	// Fetches records each fetch as "<remote>/<branch>"
+	Fetches []string
+	// Tags maps the names of the local tags to their messages
+	Tags map[string]string
+	// RemoteTags holds the tags on the remotes as "<remote>/<tag>"
+	RemoteTags map[string]bool
+	// TagPushes records each tag push as "<remote>/<tag>"
+	TagPushes []string
+	// Restored records the files restored from HEAD
+	Restored []string
+	// Stashes holds the dirty paths of each stash, oldest first
+	Stashes [][]string
+	// Worktrees holds the paths of the worktrees that have not been removed
+	Worktrees []string
+
+	// Dir is the directory of a client returned by InDir
+	Dir string
+	// root is the client holding the shared repository, nil for the root itself
+	root *FakeClient
+}
+
+var _ Client = (*FakeClient)(nil)
+
+// NewFakeClient returns a FakeClient on a clean "main" branch with an
+// "origin" remote
+func NewFakeClient() *FakeClient {
+	return &FakeClient{
+		Branch:         "main",
+		Branches:       map[string]bool{"main": true},
+		RemoteBranches: map[string]bool{"origin/main": true},
+		HeadSHA:        "0123456789abcdef0123456789abcdef01234567",
+		UserName:       "Repver Test",
+		Remotes:        map[string]string{"origin": "https://github.com/example/repo.git"},
+		PullRequestURL: "https://github.com/example/repo/pull/1",
+		Errors:         map[string]error{},
+	}
+}
+
+// CallNames returns the names of the methods called, in order
+func (f *FakeClient) CallNames() []string {
+	names := make([]string, 0, len(f.Calls))
+	for _, call := range f.Calls {
+		names = append(names, call.Method)
+	}
+	return names
+}
+
+// shared returns the client holding the repository shared by f
+func (f *FakeClient) shared() *FakeClient {
+	if f.root != nil {
+		return f.root
+	}
+	return f
+}
+
+// record stores the call, runs the OnCall hook, and returns the context's
+// error or the error configured for the method
+func (f *FakeClient) record(ctx context.Context, method string, args ...string) error {
+	r := f.shared()
+	r.Calls = append(r.Calls, Call{Method: method, Args: args, Dir: f.Dir})
+	if r.OnCall != nil {
+		r.OnCall(method)
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return r.Errors[method]
+}
+
+// InDir returns a FakeClient for a worktree at dir that shares the
+// repository of f, starting with a detached HEAD
+func (f *FakeClient) InDir(dir string) Client {
+	r := f.shared()
+	return &FakeClient{
+		Branch:         "HEAD",
+		Branches:       r.Branches,
+		RemoteBranches: r.RemoteBranches,
+		Ahead:          r.Ahead,
+		Behind:         r.Behind,
+		HeadSHA:        r.HeadSHA,
+		UserName:       r.UserName,
+		Remotes:        r.Remotes,
+		Dir:            dir,
+		root:           r,
+	}
+}
+
+func (f *FakeClient) IsGitRoot(ctx context.Context) (bool, error) {
+	if err := f.record(ctx, "IsGitRoot"); err != nil {
+		return false, err
+	}
+	return !f.NotGitRoot, nil
+}
+
+func (f *FakeClient) BranchExists(ctx context.Context, branchName string) (bool, error) {
+	if err := f.record(ctx, "BranchExists", branchName); err != nil {
+		return false, err
+	}
+	return f.Branches[branchName], nil
+}
+
+func (f *FakeClient) GetCurrentBranch(ctx context.Context) (string, error) {
+	if err := f.record(ctx, "GetCurrentBranch"); err != nil {
+		return "", err
+	}
+	return f.Branch, nil
+}
+
+func (f *FakeClient) SwitchToBranch(ctx context.Context, branchName string) (string, error) {
+	if err := f.record(ctx, "SwitchToBranch", branchName); err != nil {
+		return "", err
+	}
+	if !f.Branches[branchName] {
+		return "", fmt.Errorf("error switching to branch %s: branch does not exist", branchName)
+	}
+	f.Branch = branchName
+	return "", nil
+}
+
+func (f *FakeClient) CheckGitClean(ctx context.Context, ignore ...string) error {
+	if err := f.record(ctx, "CheckGitClean", ignore...); err != nil {
+		return err
+	}
+	for _, path := range f.Dirty {
+		if !slices.Contains(ignore, path) {
+			return ErrNotClean
+		}
+	}
+	return nil
+}
+
+func (f *FakeClient) ChangedFiles(ctx context.Context) ([]ChangedFile, error) {
+	if err := f.record(ctx, "ChangedFiles"); err != nil {
+		return nil, err
+	}
+	var files []ChangedFile
+	for _, path := range f.Dirty {
+		files = append(files, ChangedFile{Path: path, Untracked: slices.Contains(f.Untracked, path)})
+	}
+	return files, nil
+}
+
+func (f *FakeClient) CreateAndSwitchBranch(ctx context.Context, branchName string, startPoint string) (string, error) {
+	if err := f.record(ctx, "CreateAndSwitchBranch", branchName, startPoint); err != nil {
+		return "", err
+	}
+	if f.Branches[branchName] {
+		return "", fmt.Errorf("error creating and switching to branch %s: branch already exists", branchName)
+	}
+	if f.Branches == nil {
+		f.Branches = make(map[string]bool)
+	}
+	f.Branches[branchName] = true
+	f.Branch = branchName
+	return "", nil
+}
+
+func (f *FakeClient) AddAndCommitFiles(ctx context.Context, fileNames []string, commitMessage string, options CommitOptions) (string, error) {
+	if err := f.record(ctx, "AddAndCommitFiles", append(slices.Clone(fileNames), commitMessage)...); err != nil {
+		return "", err
+	}
+	r := f.shared()
+	r.Commits = append(r.Commits, FakeCommit{
+		Branch:  f.Branch,
+		Files:   slices.Clone(fileNames),
+		Message: commitMessage,
+		Options: options,
+	})
+	return "", nil
+}
+
+func (f *FakeClient) PushChanges(ctx context.Context, remote string, branch string, force bool) (string, error) {
+	if err := f.record(ctx, "PushChanges", remote, branch, fmt.Sprint(force)); err != nil {
+		return "", err
+	}
+	r := f.shared()
+	if force {
+		r.ForcePushes = append(r.ForcePushes, remote+"/"+branch)
+	} else {
+		r.Pushes = append(r.Pushes, remote+"/"+branch)
+	}
+	return "", nil
+}
+
+func (f *FakeClient) DeleteLocalBranch(ctx context.Context, branchName string) (string, error) {
+	if err := f.record(ctx, "DeleteLocalBranch", branchName); err != nil {
+		return "", err
+	}
+	if !f.Branches[branchName] {
+		return "", fmt.Errorf("error deleting local branch %s: branch does not exist", branchName)
+	}
+	delete(f.Branches, branchName)
+	return "", nil
+}
+
+func (f *FakeClient) GetShortSHA(ctx context.Context) (string, error) {
+	if err := f.record(ctx, "GetShortSHA"); err != nil {
+		return "", err
+	}
+	return f.HeadSHA[:min(7, len(f.HeadSHA))], nil
+}
+
+func (f *FakeClient) GetHeadSHA(ctx context.Context) (string, error) {
+	if err := f.record(ctx, "GetHeadSHA"); err != nil {
+		return "", err
+	}
+	return f.HeadSHA, nil
+}
+
+func (f *FakeClient) GetUserName(ctx context.Context) (string, error) {
+	if err := f.record(ctx, "GetUserName"); err != nil {
+		return "", err
+	}
+	return f.UserName, nil
+}
+
+func (f *FakeClient) GetRemoteURL(ctx context.Context, remote string) (string, error) {
+	if err := f.record(ctx, "GetRemoteURL", remote); err != nil {
+		return "", err
+	}
+	url, ok := f.Remotes[remote]
+	if !ok {
+		return "", fmt.Errorf("error getting url for remote %s: no such remote", remote)
+	}
+	return url, nil
+}
+
+func (f *FakeClient) RestoreFiles(ctx context.Context, fileNames []string) (string, error) {
+	if err := f.record(ctx, "RestoreFiles", fileNames...); err != nil {
+		return "", err
+	}
+	r := f.shared()
+	r.Restored = append(r.Restored, fileNames...)
+	return "", nil
+}
+
+func (f *FakeClient) CreateGitHubPullRequest(ctx context.Context, base string) (string, error) {
+	if err := f.record(ctx, "CreateGitHubPullRequest", base); err != nil {
+		return "", err
+	}
+	r := f.shared()
+	r.PullRequests++
+	r.PullRequestBase = base
+	return r.PullRequestURL + "\n", nil
+}
+
+func (f *FakeClient) GetGitHubPullRequestURL(ctx context.Context, branch string) (string, error) {
+	if err := f.record(ctx, "GetGitHubPullRequestURL", branch); err != nil {
+		return "", err
+	}
+	return f.shared().OpenPullRequests[branch], nil
+}
+
+func (f *FakeClient) ResetAndSwitchBranch(ctx context.Context, branchName string, startPoint string) (string, error) {
+	if err := f.record(ctx, "ResetAndSwitchBranch", branchName, startPoint); err != nil {
+		return "", err
+	}
+	if f.Branches == nil {
+		f.Branches = make(map[string]bool)
+	}
+	f.Branches[branchName] = true
+	f.Branch = branchName
+	return "", nil
+}
+
+func (f *FakeClient) ResetBranch(ctx context.Context, branchName string, commit string) (string, error) {
+	if err := f.record(ctx, "ResetBranch", branchName, commit); err != nil {
+		return "", err
+	}
+	if !f.Branches[branchName] {
+		return "", fmt.Errorf("error resetting branch %s to %s: branch does not exist", branchName, commit)
+	}
+	r := f.shared()
+	r.Resets = append(r.Resets, branchName+"@"+commit)
+	return "", nil
+}
+
+func (f *FakeClient) ResolveRef(ctx context.Context, ref string) (string, error) {
+	if err := f.record(ctx, "ResolveRef", ref); err != nil {
+		return "", err
+	}
+	if !f.Branches[ref] && !f.RemoteBranches[ref] {
+		return "", fmt.Errorf("error resolving %s: unknown revision", ref)
+	}
+	return f.HeadSHA, nil
+}
+
+func (f *FakeClient) Fetch(ctx context.Context, remote string, branch string) (string, error) {
+	if err := f.record(ctx, "Fetch", remote, branch); err != nil {
+		return "", err
+	}
+	r := f.shared()
+	r.Fetches = append(r.Fetches, remote+"/"+branch)
+	return "", nil
+}
+
+// ValidRefName rejects names with whitespace, "..", or characters git does not
+// allow in ref names, a subset of the rules of git check-ref-format.
+func (f *FakeClient) ValidRefName(ctx context.Context, ref string) (bool, error) {
+	if err := f.record(ctx, "ValidRefName", ref); err != nil {
+		return false, err
+	}
+	return !strings.ContainsAny(ref, " \t\n~^:?*[\\") && !strings.Contains(ref, ".."), nil
+}
+
+func (f *FakeClient) RefExists(ctx context.Context, ref string) (bool, error) {
+	if err := f.record(ctx, "RefExists", ref); err != nil {
+		return false, err
+	}
+	if tagName, ok := strings.CutPrefix(ref, "refs/tags/"); ok {
+		_, exists := f.shared().Tags[tagName]
+		return exists, nil
+	}
+	return f.Branches[ref] || f.RemoteBranches[ref], nil
+}
+
+func (f *FakeClient) RemoteTagExists(ctx context.Context, remote string, tagName string) (bool, error) {
+	if err := f.record(ctx, "RemoteTagExists", remote, tagName); err != nil {
+		return false, err
+	}
+	return f.shared().RemoteTags[remote+"/"+tagName], nil
+}
+
+func (f *FakeClient) CreateTag(ctx context.Context, tagName string, options TagOptions) (string, error) {
+	if err := f.record(ctx, "CreateTag", tagName, options.Message, fmt.Sprint(options.Sign), options.SignFormat); err != nil {
+		return "", err
+	}
+	r := f.shared()
+	if _, exists := r.Tags[tagName]; exists {
+		return "", fmt.Errorf("tag %s already exists", tagName)
+	}
+	if r.Tags == nil {
+		r.Tags = map[string]string{}
+	}
+	r.Tags[tagName] = options.Message
+	return "", nil
+}
+
+func (f *FakeClient) PushTag(ctx context.Context, remote string, tagName string) (string, error) {
+	if err := f.record(ctx, "PushTag", remote, tagName); err != nil {
+		return "", err
+	}
+	r := f.shared()
+	r.TagPushes = append(r.TagPushes, remote+"/"+tagName)
+	if r.RemoteTags == nil {
+		r.RemoteTags = map[string]bool{}
+	}
+	r.RemoteTags[remote+"/"+tagName] = true
+	return "", nil
+}
+
+func (f *FakeClient) DeleteTag(ctx context.Context, tagName string) (string, error) {
+	if err := f.record(ctx, "DeleteTag", tagName); err != nil {
+		return "", err
+	}
+	r := f.shared()
+	if _, exists := r.Tags[tagName]; !exists {
+		return "", fmt.Errorf("tag %s not found", tagName)
+	}
+	delete(r.Tags, tagName)
+	return "", nil
+}
+
+func (f *FakeClient) CountDivergence(ctx context.Context, local string, upstream string) (int, int, error) {
+	if err := f.record(ctx, "CountDivergence", local, upstream); err != nil {
+		return 0, 0, err
+	}
+	return f.Ahead, f.Behind, nil
+}
+
+func (f *FakeClient) StashChanges(ctx context.Context, message string) (string, error) {
+	if err := f.record(ctx, "StashChanges", message); err != nil {
+		return "", err
+	}
+	if len(f.Dirty) == 0 {
+		return "No local changes to save\n", nil
+	}
+	f.Stashes = append(f.Stashes, f.Dirty)
+	f.Dirty = nil
+	return "", nil
+}
+
+func (f *FakeClient) PopStash(ctx context.Context) (string, error) {
+	if err := f.record(ctx, "PopStash"); err != nil {
+		return "", err
+	}
+	if len(f.Stashes) == 0 {
+		return "", fmt.Errorf("error restoring stashed changes: no stash entries found")
+	}
+	f.Dirty = f.Stashes[len(f.Stashes)-1]
+	f.Stashes = f.Stashes[:len(f.Stashes)-1]
+	return "", nil
+}
+
+func (f *FakeClient) AddWorktree(ctx context.Context, path string) (string, error) {
+	if err := f.record(ctx, "AddWorktree", path); err != nil {
+		return "", err
+	}
+	for name, content := range f.Files {
+		file := filepath.Join(path, name)
+		if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+			return "", err
+		}
+		if err := os.WriteFile(file, []byte(content), 0644); err != nil {
+			return "", err
+		}
+	}
+	f.Worktrees = append(f.Worktrees, path)
+	return "", nil
+}
+
+func (f *FakeClient) RemoveWorktree(ctx context.Context, path string) (string, error) {
+	if err := f.record(ctx, "RemoveWorktree", path); err != nil {
+		return "", err
+	}
+	if !slices.Contains(f.Worktrees, path) {
+		return "", fmt.Errorf("error removing worktree at %s: not a working tree", path)
+	}
+	f.Worktrees = slices.DeleteFunc(f.Worktrees, func(p string) bool { return p == path })
+	return "", os.RemoveAll(path)
+}
+
+// BranchNames returns the sorted names of the local branches
+func (f *FakeClient) BranchNames() []string {
+	return slices.Sorted(maps.Keys(f.Branches))
+}
@@ -1,35 +1,43 @@
 package git
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"os"
-	"os/exec"
+	"slices"
 	"strings"
 )
 
+// ErrNotClean is returned by CheckGitClean when there are uncommitted changes
+var ErrNotClean = errors.New("git repository is not clean")
+
 // IsGitRoot checks if the current working directory is the root of a Git repository.
-func IsGitRoot() (bool, error) {
-	cwd, err := os.Getwd()
-	if err != nil {
-		return false, fmt.Errorf("error getting working directory: %w", err)
+func (c ExecClient) IsGitRoot(ctx context.Context) (bool, error) {
+	cwd := c.Dir
+	if cwd == "" {
+		var err error
+		cwd, err = os.Getwd()
+		if err != nil {
+			return false, fmt.Errorf("error getting working directory: %w", err)
+		}
 	}
 
-	cmd := exec.Command("git", "rev-parse", "--show-toplevel")
-	output, err := cmd.Output()
+	output, err := c.run(ctx, "git", "rev-parse", "--show-toplevel")
 	if err != nil {
 		return false, err
 	}
 
-	gitRoot := strings.TrimSpace(string(output))
+	gitRoot := strings.TrimSpace(output)
 	return cwd == gitRoot, nil
 }
 
 // BranchExists checks if a branch with the given name exists in the Git repository.
-func BranchExists(branchName string) (bool, error) {
-	cmd := exec.Command("git", "show-ref", "--verify", "--quiet", "refs/heads/"+branchName)
-	err := cmd.Run()
+func (c ExecClient) BranchExists(ctx context.Context, branchName string) (bool, error) {
+	_, err := c.run(ctx, "git", "show-ref", "--verify", "--quiet", "refs/heads/"+branchName)
 	if err != nil {
-		if exitError, ok := err.(*exec.ExitError); ok && exitError.ExitCode() == 1 {
+		var gitErr *GitError
+		if errors.As(err, &gitErr) && gitErr.ExitCode == 1 {
 			return false, nil
 		}
 		return false, fmt.Errorf("error checking branch existence: %w", err)
@@ -38,92 +46,387 @@ func BranchExists(branchName string) (bool, error) {
 }
 
 // GetCurrentBranch retrieves the name of the current branch in the Git repository.
-func GetCurrentBranch() (string, error) {
-	cmd := exec.Command("git", "rev-parse", "--abbrev-ref", "HEAD")
-	output, err := cmd.Output()
+func (c ExecClient) GetCurrentBranch(ctx context.Context) (string, error) {
+	output, err := c.run(ctx, "git", "rev-parse", "--abbrev-ref", "HEAD")
 	if err != nil {
 		return "", fmt.Errorf("error getting current branch: %w", err)
 	}
-	return strings.TrimSpace(string(output)), nil
+	return strings.TrimSpace(output), nil
 }
 
 // SwitchToBranch switches to the specified branch in the Git repository.
 // Returns the command output for logging purposes.
-func SwitchToBranch(branchName string) (string, error) {
-	cmd := exec.Command("git", "checkout", branchName)
-	output, err := cmd.Output()
+func (c ExecClient) SwitchToBranch(ctx context.Context, branchName string) (string, error) {
+	output, err := c.run(ctx, "git", "checkout", branchName)
 	if err != nil {
 		return "", fmt.Errorf("error switching to branch %s: %w", branchName, err)
 	}
-	return string(output), nil
+	return output, nil
 }
 
 // CheckGitClean checks if the Git repository is clean (i.e., no uncommitted changes).
-func CheckGitClean() error {
-	cmd := exec.Command("git", "status", "--porcelain")
-	output, err := cmd.Output()
+// Paths listed in ignore, relative to the repository root, are not considered.
+func (c ExecClient) CheckGitClean(ctx context.Context, ignore ...string) error {
+	output, err := c.run(ctx, "git", "status", "--porcelain")
 	if err != nil {
 		return fmt.Errorf("error checking git status: %w", err)
 	}
-	if len(output) > 0 {
-		return fmt.Errorf("git repository is not clean")
+	for _, line := range strings.Split(output, "\n") {
+		if len(line) < 4 || slices.Contains(ignore, line[3:]) {
+			continue
+		}
+		return ErrNotClean
 	}
 	return nil
 }
 
-// CreateAndSwitchBranch creates a new branch and switches to it.
+// ChangedFiles lists the paths with uncommitted changes, including every
+// untracked file rather than only their directories. Renamed files are
+// reported by their new path.
+func (c ExecClient) ChangedFiles(ctx context.Context) ([]ChangedFile, error) {
+	output, err := c.run(ctx, "git", "status", "--porcelain", "--untracked-files=all", "-z")
+	if err != nil {
+		return nil, fmt.Errorf("error checking git status: %w", err)
+	}
+
+	var files []ChangedFile
+	entries := strings.Split(output, "\x00")
+	for i := 0; i < len(entries); i++ {
+		entry := entries[i]
+		if len(entry) < 4 {
+			continue
+		}
+		files = append(files, ChangedFile{Path: entry[3:], Untracked: entry[:2] == "??"})
+		// A rename or copy is followed by the original path
+		if entry[0] == 'R' || entry[0] == 'C' {
+			i++
+		}
+	}
+	return files, nil
+}
+
+// CreateAndSwitchBranch creates a new branch at startPoint, or HEAD if empty, and switches to it.
+// The branch does not track startPoint, so it is pushed and compared as a branch of its own.
 // Returns the command output for logging purposes.
-func CreateAndSwitchBranch(branchName string) (string, error) {
-	cmd := exec.Command("git", "checkout", "-b", branchName)
-	output, err := cmd.Output()
+func (c ExecClient) CreateAndSwitchBranch(ctx context.Context, branchName string, startPoint string) (string, error) {
+	args := []string{"checkout", "--no-track", "-b", branchName}
+	if startPoint != "" {
+		args = append(args, startPoint)
+	}
+	output, err := c.run(ctx, "git", args...)
 	if err != nil {
 		return "", fmt.Errorf("error creating and switching to branch %s: %w", branchName, err)
 	}
-	return string(output), nil
+	return output, nil
 }
 
 // AddAndCommitFiles adds files to the staging area and commits them with a message.
 // Returns the commit output for logging purposes.
-func AddAndCommitFiles(fileNames []string, commitMessage string) (string, error) {
+func (c ExecClient) AddAndCommitFiles(ctx context.Context, fileNames []string, commitMessage string, options CommitOptions) (string, error) {
 	var output strings.Builder
 
 	for _, fileName := range fileNames {
-		cmd := exec.Command("git", "add", fileName)
-		addOutput, err := cmd.Output()
+		addOutput, err := c.run(ctx, "git", "add", fileName)
 		if err != nil {
 			return "", fmt.Errorf("error adding file %s: %w", fileName, err)
 		}
-		output.WriteString(string(addOutput))
+		output.WriteString(addOutput)
 	}
 
-	cmd := exec.Command("git", "commit", "-m", commitMessage)
-	commitOutput, err := cmd.Output()
+	commitOutput, err := c.run(ctx, "git", commitArgs(commitMessage, options)...)
 	if err != nil {
 		return "", fmt.Errorf("error committing changes: %w", err)
 	}
-	output.WriteString(string(commitOutput))
+	output.WriteString(commitOutput)
 
 	return output.String(), nil
 }
 
-// PushChanges pushes the changes to the specified remote and branch.
+// commitArgs returns the arguments to git that commit the staged changes with the options
+func commitArgs(commitMessage string, options CommitOptions) []string {
+	var args []string
+	if options.SignFormat != "" {
+		args = append(args, "-c", "gpg.format="+options.SignFormat)
+	}
+	args = append(args, "commit")
+	if options.Sign {
+		args = append(args, "-S")
+	}
+	if options.Author != "" {
+		args = append(args, "--author="+options.Author)
+	}
+	if options.Signoff {
+		args = append(args, "--signoff")
+	}
+	return append(args, "-m", commitMessage)
+}
+
+// PushChanges pushes the changes to the specified remote and branch. With
+// force, the remote branch is replaced only if it is where it was last fetched.
 // Returns the command output for logging purposes.
-func PushChanges(remote string, branch string) (string, error) {
-	cmd := exec.Command("git", "push", remote, branch)
-	output, err := cmd.Output()
+func (c ExecClient) PushChanges(ctx context.Context, remote string, branch string, force bool) (string, error) {
+	args := []string{"push", remote, branch}
+	if force {
+		args = []string{"push", "--force-with-lease", remote, branch}
+	}
+	output, err := c.run(ctx, "git", args...)
 	if err != nil {
 		return "", fmt.Errorf("error pushing changes to %s/%s: %w", remote, branch, err)
 	}
-	return string(output), nil
+	return output, nil
 }
 
 // DeleteLocalBranch deletes a local branch with the specified name.
 // Returns the command output for logging purposes.
-func DeleteLocalBranch(branchName string) (string, error) {
-	cmd := exec.Command("git", "branch", "-D", branchName)
-	output, err := cmd.Output()
+func (c ExecClient) DeleteLocalBranch(ctx context.Context, branchName string) (string, error) {
+	output, err := c.run(ctx, "git", "branch", "-D", branchName)
 	if err != nil {
 		return "", fmt.Errorf("error deleting local branch %s: %w", branchName, err)
 	}
-	return string(output), nil
+	return output, nil
+}
+
+// GetShortSHA retrieves the abbreviated commit hash of HEAD.
+func (c ExecClient) GetShortSHA(ctx context.Context) (string, error) {
+	output, err := c.run(ctx, "git", "rev-parse", "--short", "HEAD")
+	if err != nil {
+		return "", fmt.Errorf("error getting short commit hash: %w", err)
+	}
+	return strings.TrimSpace(output), nil
+}
+
+// GetHeadSHA retrieves the full commit hash of HEAD.
+func (c ExecClient) GetHeadSHA(ctx context.Context) (string, error) {
+	output, err := c.run(ctx, "git", "rev-parse", "HEAD")
+	if err != nil {
+		return "", fmt.Errorf("error getting commit hash: %w", err)
+	}
+	return strings.TrimSpace(output), nil
+}
+
+// GetUserName retrieves the configured Git user name.
+func (c ExecClient) GetUserName(ctx context.Context) (string, error) {
+	output, err := c.run(ctx, "git", "config", "user.name")
+	if err != nil {
+		return "", fmt.Errorf("error getting git user name: %w", err)
+	}
+	return strings.TrimSpace(output), nil
+}
+
+// GetRemoteURL retrieves the URL of the specified remote.
+func (c ExecClient) GetRemoteURL(ctx context.Context, remote string) (string, error) {
+	output, err := c.run(ctx, "git", "remote", "get-url", remote)
+	if err != nil {
+		return "", fmt.Errorf("error getting url for remote %s: %w", remote, err)
+	}
+	return strings.TrimSpace(output), nil
+}
+
+// StashChanges stashes all uncommitted changes, including untracked files, with the message.
+// Returns the command output for logging purposes.
+func (c ExecClient) StashChanges(ctx context.Context, message string) (string, error) {
+	output, err := c.run(ctx, "git", "stash", "push", "--include-untracked", "--message", message)
+	if err != nil {
+		return "", fmt.Errorf("error stashing changes: %w", err)
+	}
+	return output, nil
+}
+
+// PopStash restores the most recently stashed changes and drops the stash.
+// Returns the command output for logging purposes.
+func (c ExecClient) PopStash(ctx context.Context) (string, error) {
+	output, err := c.run(ctx, "git", "stash", "pop")
+	if err != nil {
+		return "", fmt.Errorf("error restoring stashed changes: %w", err)
+	}
+	return output, nil
+}
+
+// AddWorktree checks out HEAD in a new worktree at path with a detached HEAD.
+// Returns the command output for logging purposes.
+func (c ExecClient) AddWorktree(ctx context.Context, path string) (string, error) {
+	output, err := c.run(ctx, "git", "worktree", "add", "--detach", path, "HEAD")
+	if err != nil {
+		return "", fmt.Errorf("error adding worktree at %s: %w", path, err)
+	}
+	return output, nil
+}
+
+// RemoveWorktree removes the worktree at path, discarding any changes in it.
+// Returns the command output for logging purposes.
+func (c ExecClient) RemoveWorktree(ctx context.Context, path string) (string, error) {
+	output, err := c.run(ctx, "git", "worktree", "remove", "--force", path)
+	if err != nil {
+		return "", fmt.Errorf("error removing worktree at %s: %w", path, err)
+	}
+	return output, nil
+}
+
+// ResetAndSwitchBranch resets a branch to startPoint, or HEAD if empty, creating
+// it if needed, and switches to it. Like CreateAndSwitchBranch, the branch does not
+// track startPoint. Returns the command output for logging purposes.
+func (c ExecClient) ResetAndSwitchBranch(ctx context.Context, branchName string, startPoint string) (string, error) {
+	args := []string{"checkout", "--no-track", "-B", branchName}
+	if startPoint != "" {
+		args = append(args, startPoint)
+	}
+	output, err := c.run(ctx, "git", args...)
+	if err != nil {
+		return "", fmt.Errorf("error resetting and switching to branch %s: %w", branchName, err)
+	}
+	return output, nil
+}
+
+// ResetBranch points an existing branch that is not checked out at the commit.
+// Returns the command output for logging purposes.
+func (c ExecClient) ResetBranch(ctx context.Context, branchName string, commit string) (string, error) {
+	output, err := c.run(ctx, "git", "branch", "--force", branchName, commit)
+	if err != nil {
+		return "", fmt.Errorf("error resetting branch %s to %s: %w", branchName, commit, err)
+	}
+	return output, nil
+}
+
+// ResolveRef returns the full commit hash a ref points to.
+func (c ExecClient) ResolveRef(ctx context.Context, ref string) (string, error) {
+	output, err := c.run(ctx, "git", "rev-parse", "--verify", ref+"^{commit}")
+	if err != nil {
+		return "", fmt.Errorf("error resolving %s: %w", ref, err)
+	}
+	return strings.TrimSpace(output), nil
+}
+
+// Fetch fetches a branch from the remote, updating its remote-tracking branch.
+// Returns the command output for logging purposes.
+func (c ExecClient) Fetch(ctx context.Context, remote string, branch string) (string, error) {
+	output, err := c.run(ctx, "git", "fetch", remote, branch)
+	if err != nil {
+		return "", fmt.Errorf("error fetching %s from %s: %w", branch, remote, err)
+	}
+	return output, nil
+}
+
+// ValidRefName checks if a full ref name, such as refs/heads/main, is well formed.
+func (c ExecClient) ValidRefName(ctx context.Context, ref string) (bool, error) {
+	_, err := c.run(ctx, "git", "check-ref-format", ref)
+	if err != nil {
+		var gitErr *GitError
+		if errors.As(err, &gitErr) && gitErr.ExitCode == 1 {
+			return false, nil
+		}
+		return false, fmt.Errorf("error checking ref name %s: %w", ref, err)
+	}
+	return true, nil
+}
+
+// RefExists checks if a ref, such as a branch or remote-tracking branch, names a commit.
+func (c ExecClient) RefExists(ctx context.Context, ref string) (bool, error) {
+	_, err := c.run(ctx, "git", "rev-parse", "--verify", "--quiet", ref+"^{commit}")
+	if err != nil {
+		var gitErr *GitError
+		if errors.As(err, &gitErr) && gitErr.ExitCode == 1 {
+			return false, nil
+		}
+		return false, fmt.Errorf("error checking ref %s: %w", ref, err)
+	}
+	return true, nil
+}
+
+// RemoteTagExists checks if the remote has a tag with the given name.
+func (c ExecClient) RemoteTagExists(ctx context.Context, remote string, tagName string) (bool, error) {
+	output, err := c.run(ctx, "git", "ls-remote", "--tags", remote, "refs/tags/"+tagName)
+	if err != nil {
+		return false, fmt.Errorf("error checking tag %s on %s: %w", tagName, remote, err)
+	}
+	return strings.TrimSpace(output) != "", nil
+}
+
+// CreateTag creates an annotated tag at HEAD, signed if the options ask for it.
+// Returns the command output for logging purposes.
+func (c ExecClient) CreateTag(ctx context.Context, tagName string, options TagOptions) (string, error) {
+	var args []string
+	if options.SignFormat != "" {
+		args = append(args, "-c", "gpg.format="+options.SignFormat)
+	}
+	if options.Sign {
+		args = append(args, "tag", "-s", tagName, "-m", options.Message)
+	} else {
+		args = append(args, "tag", "-a", tagName, "-m", options.Message)
+	}
+	output, err := c.run(ctx, "git", args...)
+	if err != nil {
+		return "", fmt.Errorf("error creating tag %s: %w", tagName, err)
+	}
+	return output, nil
+}
+
+// PushTag pushes a tag to the remote.
+// Returns the command output for logging purposes.
+func (c ExecClient) PushTag(ctx context.Context, remote string, tagName string) (string, error) {
+	output, err := c.run(ctx, "git", "push", remote, "refs/tags/"+tagName)
+	if err != nil {
+		return "", fmt.Errorf("error pushing tag %s to %s: %w", tagName, remote, err)
+	}
+	return output, nil
+}
+
+// DeleteTag deletes a local tag.
+// Returns the command output for logging purposes.
+func (c ExecClient) DeleteTag(ctx context.Context, tagName string) (string, error) {
+	output, err := c.run(ctx, "git", "tag", "-d", tagName)
+	if err != nil {
+		return "", fmt.Errorf("error deleting tag %s: %w", tagName, err)
+	}
+	return output, nil
+}
+
+// CountDivergence counts the commits on local that are not on upstream and
+// the commits on upstream that are not on local.
+func (c ExecClient) CountDivergence(ctx context.Context, local string, upstream string) (int, int, error) {
+	output, err := c.run(ctx, "git", "rev-list", "--left-right", "--count", local+"..."+upstream)
+	if err != nil {
+		return 0, 0, fmt.Errorf("error comparing %s with %s: %w", local, upstream, err)
+	}
+	var ahead, behind int
+	if _, err := fmt.Sscan(output, &ahead, &behind); err != nil {
+		return 0, 0, fmt.Errorf("error parsing commit counts %q: %w", strings.TrimSpace(output), err)
+	}
+	return ahead, behind, nil
+}
+
+// ParseRepoName extracts the owner and repository name from a remote URL.
+// Both HTTPS (https://github.com/owner/repo.git) and SCP-like SSH
+// (git@github.com:owner/repo.git) URLs are supported.
+func ParseRepoName(remoteURL string) (owner string, name string, err error) {
+	trimmed := strings.TrimSuffix(strings.TrimSuffix(strings.TrimSpace(remoteURL), "/"), ".git")
+	if trimmed == "" {
+		return "", "", fmt.Errorf("empty remote url")
+	}
+
+	// Normalize the SCP-like syntax so the path is separated by slashes
+	if !strings.Contains(trimmed, "://") {
+		if idx := strings.Index(trimmed, ":"); idx >= 0 {
+			trimmed = trimmed[:idx] + "/" + trimmed[idx+1:]
+		}
+	}
+
+	parts := strings.Split(trimmed, "/")
+	name = parts[len(parts)-1]
+	if name == "" {
+		return "", "", fmt.Errorf("could not determine repository name from %s", remoteURL)
+	}
+	if len(parts) > 1 {
+		owner = parts[len(parts)-2]
+	}
+	return owner, name, nil
+}
+
+// RestoreFiles discards staged and unstaged changes to the files, restoring them from HEAD.
+// Returns the command output for logging purposes.
+func (c ExecClient) RestoreFiles(ctx context.Context, fileNames []string) (string, error) {
+	output, err := c.run(ctx, "git", append([]string{"checkout", "HEAD", "--"}, fileNames...)...)
+	if err != nil {
+		return "", fmt.Errorf("error restoring files %v: %w", fileNames, err)
+	}
+	return output, nil
 }
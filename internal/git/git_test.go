@@ -0,0 +1,89 @@
+package git
+
+import (
+	"context"
+	"slices"
+	"testing"
+)
+
+func TestParseRepoName(t *testing.T) {
+	tests := []struct {
+		name          string
+		url           string
+		expectedOwner string
+		expectedName  string
+		shouldError   bool
+	}{
+		{"https", "https://github.com/UnitVectorY-Labs/repver.git", "UnitVectorY-Labs", "repver", false},
+		{"https without suffix", "https://github.com/UnitVectorY-Labs/repver", "UnitVectorY-Labs", "repver", false},
+		{"scp-like ssh", "git@github.com:UnitVectorY-Labs/repver.git", "UnitVectorY-Labs", "repver", false},
+		{"ssh url", "ssh://git@github.com/UnitVectorY-Labs/repver.git", "UnitVectorY-Labs", "repver", false},
+		{"local path", "/srv/git/repver.git", "git", "repver", false},
+		{"empty", "", "", "", true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			owner, name, err := ParseRepoName(tc.url)
+			if tc.shouldError {
+				if err == nil {
+					t.Errorf("expected error but got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if owner != tc.expectedOwner || name != tc.expectedName {
+				t.Errorf("ParseRepoName(%q) = (%q, %q), want (%q, %q)", tc.url, owner, name, tc.expectedOwner, tc.expectedName)
+			}
+		})
+	}
+}
+
+func TestCommitArgs(t *testing.T) {
+	tests := []struct {
+		name     string
+		options  CommitOptions
+		expected []string
+	}{
+		{"plain", CommitOptions{}, []string{"commit", "-m", "Update"}},
+		{"sign with configured format", CommitOptions{Sign: true}, []string{"commit", "-S", "-m", "Update"}},
+		{"sign with ssh", CommitOptions{Sign: true, SignFormat: "ssh"}, []string{"-c", "gpg.format=ssh", "commit", "-S", "-m", "Update"}},
+		{"author and signoff", CommitOptions{Author: "Bot <bot@example.com>", Signoff: true}, []string{"commit", "--author=Bot <bot@example.com>", "--signoff", "-m", "Update"}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := commitArgs("Update", tc.options); !slices.Equal(got, tc.expected) {
+				t.Errorf("commitArgs() = %q, want %q", got, tc.expected)
+			}
+		})
+	}
+}
+
+func TestValidRefName(t *testing.T) {
+	tests := []struct {
+		ref   string
+		valid bool
+	}{
+		{"refs/heads/repver/1.3.0", true},
+		{"refs/tags/v1.3.0", true},
+		{"refs/heads/repver/1.2, 1.3", false},
+		{"refs/heads/repver/1..3", false},
+		{"refs/tags/v1.3.0.lock", false},
+	}
+
+	client := NewExecClient(false)
+	for _, tc := range tests {
+		t.Run(tc.ref, func(t *testing.T) {
+			valid, err := client.ValidRefName(context.Background(), tc.ref)
+			if err != nil {
+				t.Fatalf("ValidRefName returned error: %v", err)
+			}
+			if valid != tc.valid {
+				t.Errorf("ValidRefName(%q) = %v, want %v", tc.ref, valid, tc.valid)
+			}
+		})
+	}
+}
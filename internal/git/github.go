@@ -1,18 +1,46 @@
 package git
 
 import (
+	"context"
 	"fmt"
-	"os/exec"
+	"strings"
 )
 
 // CreateGitHubPullRequest creates a pull request on GitHub using the GitHub CLI.
+// The pull request targets base, or the repository's default branch if empty.
 // Returns the output of the command for logging purposes.
-func CreateGitHubPullRequest() (string, error) {
-	cmd := exec.Command("gh", "pr", "create", "--fill")
-	output, err := cmd.Output()
+func (c ExecClient) CreateGitHubPullRequest(ctx context.Context, base string) (string, error) {
+	args := []string{"pr", "create", "--fill"}
+	if base != "" {
+		args = append(args, "--base", base)
+	}
+	output, err := c.run(ctx, "gh", args...)
 	if err != nil {
 		return "", fmt.Errorf("error creating GitHub pull request: %w", err)
 	}
 
-	return string(output), nil
+	return output, nil
+}
+
+// GetGitHubPullRequestURL returns the URL of the open pull request for the
+// branch using the GitHub CLI, or an empty string if there is none.
+func (c ExecClient) GetGitHubPullRequestURL(ctx context.Context, branch string) (string, error) {
+	output, err := c.run(ctx, "gh", "pr", "list", "--head", branch, "--state", "open", "--json", "url", "--jq", ".[0].url")
+	if err != nil {
+		return "", fmt.Errorf("error finding GitHub pull request for %s: %w", branch, err)
+	}
+	return strings.TrimSpace(output), nil
+}
+
+// ParsePullRequestURL extracts the pull request URL from the output of
+// CreateGitHubPullRequest, which prints the URL as its last line.
+func ParsePullRequestURL(output string) string {
+	lines := strings.Split(strings.TrimSpace(output), "\n")
+	for i := len(lines) - 1; i >= 0; i-- {
+		line := strings.TrimSpace(lines[i])
+		if strings.HasPrefix(line, "https://") || strings.HasPrefix(line, "http://") {
+			return line
+		}
+	}
+	return ""
 }
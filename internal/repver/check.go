@@ -0,0 +1,194 @@
+package repver
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"text/template/parse"
+)
+
+// Observation records a value found in a target file for a param or a named
+// group of a param pattern
+type Observation struct {
+	Path       string
+	LineNumber int
+	Name       string
+	Value      string
+}
+
+// Drift describes a param or param group that holds different values across targets
+type Drift struct {
+	Name         string
+	Observations []Observation
+}
+
+// Observe extracts the values currently held by each target of the command.
+// Groups that receive the raw param value are reported under the param name,
+// along with the named groups the param's pattern extracts from that value.
+// Groups that use a transform are reversed into the param groups the
+// transform references when the template is simple enough to invert;
+// otherwise they are skipped.
+func (c *RepverCommand) Observe() ([]Observation, error) {
+	var observations []Observation
+
+	for _, target := range c.Targets {
+		captured, err := target.Capture()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read target '%s': %w", target.Path, err)
+		}
+
+		groups, err := target.GetGroupNames()
+		if err != nil {
+			return nil, err
+		}
+
+		for _, line := range captured {
+			for _, group := range groups {
+				if target.IsMatchOnly(group) {
+					continue
+				}
+				value := line.Values[group]
+				observe := func(name string, value string) {
+					observations = append(observations, Observation{
+						Path:       target.Path,
+						LineNumber: line.LineNumber,
+						Name:       name,
+						Value:      value,
+					})
+				}
+
+				transform, transformed := target.Transforms[group]
+				if !transformed && target.Transform != "" {
+					transform, transformed = target.Transform, true
+				}
+
+				if transformed {
+					reversed, ok := ReverseTransform(transform, value)
+					if !ok {
+						Debugln("Could not reverse transform '%s' for %s:%d", transform, target.Path, line.LineNumber)
+						continue
+					}
+					for _, name := range sortedKeys(reversed) {
+						observe(name, reversed[name])
+					}
+					continue
+				}
+
+				paramName := target.GetBoundParamName(group)
+				observe(paramName, value)
+
+				if param := c.GetParam(paramName); param != nil {
+					extracted, err := param.ExtractNamedGroups(value)
+					if err != nil {
+						Debugln("Value '%s' in %s:%d does not match param '%s'", value, target.Path, line.LineNumber, paramName)
+						continue
+					}
+					for _, name := range sortedKeys(extracted) {
+						observe(name, extracted[name])
+					}
+				}
+			}
+		}
+	}
+
+	return observations, nil
+}
+
+// CheckDrift returns every param or param group that holds more than one
+// distinct value across the command's targets, sorted by name.
+func (c *RepverCommand) CheckDrift() ([]Drift, error) {
+	observations, err := c.Observe()
+	if err != nil {
+		return nil, err
+	}
+
+	byName := make(map[string][]Observation)
+	for _, observation := range observations {
+		byName[observation.Name] = append(byName[observation.Name], observation)
+	}
+
+	var drifts []Drift
+	for _, name := range sortedKeys(byName) {
+		values := make(map[string]bool)
+		for _, observation := range byName[name] {
+			values[observation.Value] = true
+		}
+		if len(values) > 1 {
+			drifts = append(drifts, Drift{Name: name, Observations: byName[name]})
+		}
+	}
+
+	return drifts, nil
+}
+
+// ReverseTransform recovers the values of the variables referenced by a
+// transform from its rendered output. Only templates made up of literal text
+// and plain {{name}} placeholders can be reversed; templates that use
+// functions, pipelines, conditionals, dotted variables, or place two
+// placeholders next to each other return false.
+func ReverseTransform(transform string, value string) (map[string]string, bool) {
+	tmpl, err := parseTemplate(transform)
+	if err != nil || tmpl.Tree == nil {
+		return nil, false
+	}
+
+	var pattern strings.Builder
+	var names []string
+	previousWasField := false
+	pattern.WriteString("^")
+	for _, node := range tmpl.Tree.Root.Nodes {
+		switch n := node.(type) {
+		case *parse.TextNode:
+			pattern.WriteString(regexp.QuoteMeta(string(n.Text)))
+			previousWasField = len(n.Text) == 0 && previousWasField
+		case *parse.ActionNode:
+			if previousWasField || len(n.Pipe.Decl) > 0 || len(n.Pipe.Cmds) != 1 || len(n.Pipe.Cmds[0].Args) != 1 {
+				return nil, false
+			}
+			field, ok := n.Pipe.Cmds[0].Args[0].(*parse.FieldNode)
+			if !ok || len(field.Ident) != 1 {
+				return nil, false
+			}
+			pattern.WriteString("(.+?)")
+			names = append(names, field.Ident[0])
+			previousWasField = true
+		default:
+			return nil, false
+		}
+	}
+	pattern.WriteString("$")
+
+	if len(names) == 0 {
+		return nil, false
+	}
+
+	re, err := regexp.Compile(pattern.String())
+	if err != nil {
+		return nil, false
+	}
+	matches := re.FindStringSubmatch(value)
+	if matches == nil {
+		return nil, false
+	}
+
+	result := make(map[string]string)
+	for i, name := range names {
+		if existing, ok := result[name]; ok && existing != matches[i+1] {
+			return nil, false
+		}
+		result[name] = matches[i+1]
+	}
+
+	return result, true
+}
+
+// sortedKeys returns the keys of a map in sorted order
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,91 @@
+package repver
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestReverseTransform(t *testing.T) {
+	tests := []struct {
+		name      string
+		transform string
+		value     string
+		expected  map[string]string
+		ok        bool
+	}{
+		{"major minor", "{{major}}.{{minor}}", "1.22", map[string]string{"major": "1", "minor": "22"}, true},
+		{"with prefix", "v{{major}}.{{minor}}.{{patch}}", "v1.22.3", map[string]string{"major": "1", "minor": "22", "patch": "3"}, true},
+		{"field syntax", "{{ .major }}.x", "2.x", map[string]string{"major": "2"}, true},
+		{"repeated placeholder", "{{major}}-{{major}}", "1-1", map[string]string{"major": "1"}, true},
+		{"repeated placeholder disagrees", "{{major}}-{{major}}", "1-2", nil, false},
+		{"value does not match", "v{{major}}", "1", nil, false},
+		{"adjacent placeholders", "{{major}}{{minor}}", "122", nil, false},
+		{"function", `{{ upper .name }}`, "GO", nil, false},
+		{"dotted variable", "{{old.version}}", "1.0", nil, false},
+		{"static text", "static", "static", nil, false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, ok := ReverseTransform(tc.transform, tc.value)
+			if ok != tc.ok {
+				t.Fatalf("expected ok=%v, got ok=%v (%v)", tc.ok, ok, got)
+			}
+			if tc.ok && !reflect.DeepEqual(got, tc.expected) {
+				t.Errorf("expected %v, got %v", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestCheckDrift(t *testing.T) {
+	tmpDir := t.TempDir()
+	goMod := filepath.Join(tmpDir, "go.mod")
+	workflow := filepath.Join(tmpDir, "build.yml")
+	if err := os.WriteFile(goMod, []byte("go 1.22 // GOVERSION\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	command := RepverCommand{
+		Name: "goversion",
+		Params: []RepverParam{
+			{Name: "version", Pattern: `^(?P<major>\d+)\.(?P<minor>\d+)\.(?P<patch>\d+)$`},
+		},
+		Targets: []RepverTarget{
+			{Path: goMod, Pattern: `^go (?P<version>.*) // GOVERSION$`, Transform: "{{major}}.{{minor}}"},
+			{Path: workflow, Pattern: `^go-version: '(?P<version>.*)'$`},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		workflow string
+		drifted  []string
+	}{
+		{"targets agree", "go-version: '1.22.5'\n", nil},
+		{"minor version differs", "go-version: '1.23.0'\n", []string{"minor"}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if err := os.WriteFile(workflow, []byte(tc.workflow), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			drifts, err := command.CheckDrift()
+			if err != nil {
+				t.Fatalf("CheckDrift returned error: %v", err)
+			}
+
+			var names []string
+			for _, drift := range drifts {
+				names = append(names, drift.Name)
+			}
+			if !reflect.DeepEqual(names, tc.drifted) {
+				t.Errorf("expected drift in %v, got %v", tc.drifted, names)
+			}
+		})
+	}
+}
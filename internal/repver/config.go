@@ -3,11 +3,23 @@ package repver
 import (
 	"fmt"
 	"regexp"
+	"slices"
+	"sort"
 	"strings"
+	"time"
 )
 
-// Pre-compiled regex pattern for placeholder extraction
-var placeholderRegex = regexp.MustCompile(`\{\{([^}]+)\}\}`)
+// DefaultGitTimeout is the time limit for each git or gh step when no timeout is configured
+const DefaultGitTimeout = 5 * time.Minute
+
+// DefaultHookTimeout is the time limit for each hook command when no timeout is configured
+const DefaultHookTimeout = 10 * time.Minute
+
+// HookStages lists the points of a run at which hooks run, in order
+var HookStages = []string{"pre_plan", "post_apply", "pre_commit"}
+
+// GitSteps lists the steps whose time limit can be set in timeouts
+var GitSteps = []string{"query", "create_branch", "commit", "tag", "push", "pull_request", "switch_branch", "delete_branch", "restore_files", "stash", "worktree", "fetch"}
 
 type RepverConfig struct {
 	// Commands is an array of version modification commands
@@ -21,17 +33,48 @@ type RepverParam struct {
 	// Pattern is the regex pattern to validate and extract values from the parameter
 	// It can contain named capture groups (e.g., (?P<major>\d+)) for use in transforms
 	Pattern string `yaml:"pattern"`
+	// Description explains the purpose of the parameter
+	Description string `yaml:"description"`
+	// Example is a sample value shown when prompting for the parameter
+	Example string `yaml:"example"`
 }
 
 type RepverCommand struct {
 	// Name of the command
 	Name string `yaml:"name"`
+	// Description explains what the command updates
+	Description string `yaml:"description"`
 	// Params defines optional validation patterns for command-line parameters
 	Params []RepverParam `yaml:"params"`
 	// Targets is a list of files and patterns to modify
 	Targets []RepverTarget `yaml:"targets"`
 	// GitOptions configures Git operations to perform
 	GitOptions RepverGit `yaml:"git"`
+	// Monotonic refuses changes that lower a target's current value (values: semver, numeric, lexical)
+	Monotonic string `yaml:"monotonic"`
+	// Source is the path of the target whose captured values supply the params in sync mode
+	Source string `yaml:"source"`
+	// Hooks are shell commands run before planning, after the targets are written and before committing
+	Hooks RepverHooks `yaml:"hooks"`
+	// Verify are shell commands that must succeed after the targets are written
+	// for the changes to be committed; they use the hooks timeout
+	Verify []string `yaml:"verify"`
+}
+
+// RepverHooks defines the shell commands run at each stage of a command. Each
+// command is a template and runs with the params and groups as environment
+// variables; files it changes are added to the commit.
+type RepverHooks struct {
+	// PrePlan commands run before the targets are planned
+	PrePlan []string `yaml:"pre_plan"`
+	// PostApply commands run after the target files are written
+	PostApply []string `yaml:"post_apply"`
+	// PreCommit commands run before the changes are committed
+	PreCommit []string `yaml:"pre_commit"`
+	// Timeout is the time limit for each command, including the verify commands,
+	// as a duration such as "2m"
+	// A value of "0" disables the limit; defaults to DefaultHookTimeout
+	Timeout string `yaml:"timeout"`
 }
 
 type RepverGit struct {
@@ -42,10 +85,42 @@ type RepverGit struct {
 	DeleteBranch bool `yaml:"delete_branch"`
 	// BranchName is the name for the new branch
 	BranchName string `yaml:"branch_name"`
+	// BaseBranch is the branch the new branch is created from, and the base of the pull request
+	// Either a local branch (main) or a remote-tracking branch of the remote (origin/main)
+	// If not specified, the new branch is created from the current HEAD
+	BaseBranch string `yaml:"base_branch"`
+	// Fetch indicates whether to fetch the base branch from the remote before branching
+	Fetch bool `yaml:"fetch"`
+	// OnExistingBranch decides what happens when the branch already exists (values: fail, reuse, recreate, suffix)
+	// reuse adds a commit to the branch, recreate resets it to the base and force pushes it,
+	// and suffix creates the branch with -2, -3 and so on appended instead
+	OnExistingBranch string `yaml:"on_existing_branch"`
 	// Commit indicates whether to commit changes
 	Commit bool `yaml:"commit"`
 	// CommitMessage is the message to use for the commit
 	CommitMessage string `yaml:"commit_message"`
+	// CommitBody is an optional multi-line template added to the commit message after a blank line
+	CommitBody string `yaml:"commit_body"`
+	// Trailers are templated key/value pairs, such as Co-authored-by, added to the end of the commit message
+	// They are written in the order of their keys
+	Trailers map[string]string `yaml:"trailers"`
+	// Sign signs the commit (values: true, gpg, ssh)
+	// true uses the signing format configured in git; gpg and ssh select the format
+	Sign string `yaml:"sign"`
+	// Author overrides the author of the commit, in the form "Name <email>"
+	Author string `yaml:"author"`
+	// Signoff adds a Signed-off-by trailer for the committer to the commit message
+	Signoff bool `yaml:"signoff"`
+	// Tag indicates whether to create an annotated tag on the commit
+	Tag bool `yaml:"tag"`
+	// TagName is the name of the tag
+	TagName string `yaml:"tag_name"`
+	// TagMessage is the message of the tag; defaults to the tag name
+	TagMessage string `yaml:"tag_message"`
+	// TagSign signs the tag (values: true, gpg, ssh), like Sign for the commit
+	TagSign string `yaml:"tag_sign"`
+	// PushTags indicates whether to push the tag to the remote
+	PushTags bool `yaml:"push_tags"`
 	// Push indicates whether to push changes
 	Push bool `yaml:"push"`
 	// Remote is the Git remote to push to
@@ -54,17 +129,93 @@ type RepverGit struct {
 	PullRequest string `yaml:"pull_request"`
 	// ReturnToOriginalBranch indicates whether to switch back to the original branch
 	ReturnToOriginalBranch bool `yaml:"return_to_original_branch"`
+	// DirtyTree is the policy for uncommitted changes in the workspace (values: fail, stash, worktree)
+	// With stash they are stashed and restored around the run; with worktree the git
+	// operations run in a temporary worktree and the checkout is never touched
+	DirtyTree string `yaml:"dirty_tree"`
+	// Timeout is the time limit for each git or gh step, as a duration such as "2m"
+	// A value of "0" disables the limit; defaults to DefaultGitTimeout
+	Timeout string `yaml:"timeout"`
+	// Timeouts overrides Timeout for individual steps, keyed by the names in GitSteps
+	Timeouts map[string]string `yaml:"timeouts"`
 }
 
 type RepverTarget struct {
 	// Path to the target file
 	Path string `yaml:"path"`
+	// Description explains what the target file is
+	Description string `yaml:"description"`
 	// Pattern is the regex pattern to match content in the target file
 	Pattern string `yaml:"pattern"`
 	// Transform specifies how to transform parameter values using named groups from params
-	// Uses {{name}} syntax to reference named groups from the params pattern
+	// It is a Go text/template; the bare {{name}} syntax references named groups from the params pattern
 	// If not specified, the raw parameter value is used
 	Transform string `yaml:"transform"`
+	// Transforms maps individual named groups in the pattern to their own transform template
+	// Groups with a transform here are derived from params and are not command-line parameters
+	// Cannot be combined with Transform
+	Transforms map[string]string `yaml:"transforms"`
+	// Bind maps named groups in the pattern to the parameter that supplies their value
+	// Groups that are not bound use their own name as the parameter name
+	Bind map[string]string `yaml:"bind"`
+	// MatchOnly lists named groups that must match but are never replaced
+	// These groups act as read-only guards and are not parameters
+	MatchOnly []string `yaml:"match_only"`
+}
+
+// Commands returns the commands of the stage, as written in the configuration
+func (h *RepverHooks) Commands(stage string) []string {
+	switch stage {
+	case "pre_plan":
+		return h.PrePlan
+	case "post_apply":
+		return h.PostApply
+	case "pre_commit":
+		return h.PreCommit
+	}
+	return nil
+}
+
+// Specified checks if any hook commands are configured
+func (h *RepverHooks) Specified() bool {
+	return len(h.PrePlan) > 0 || len(h.PostApply) > 0 || len(h.PreCommit) > 0
+}
+
+// RenderCommands renders the commands of the stage with values
+func (h *RepverHooks) RenderCommands(stage string, vals map[string]string) ([]string, error) {
+	return renderCommands(h.Commands(stage), vals)
+}
+
+// RenderVerify renders the verify commands with values
+func (c *RepverCommand) RenderVerify(vals map[string]string) ([]string, error) {
+	return renderCommands(c.Verify, vals)
+}
+
+// renderCommands renders each of the shell commands with values, quoting the
+// output of every action with RenderShellTemplate.
+func renderCommands(templates []string, vals map[string]string) ([]string, error) {
+	var commands []string
+	for _, command := range templates {
+		rendered, err := RenderShellTemplate(command, vals)
+		if err != nil {
+			return nil, err
+		}
+		commands = append(commands, rendered)
+	}
+	return commands, nil
+}
+
+// CommandTimeout returns the time limit for each hook command, or 0 if it has no limit.
+// Invalid durations are rejected by validation and fall back to the default here.
+func (h *RepverHooks) CommandTimeout() time.Duration {
+	if h.Timeout == "" {
+		return DefaultHookTimeout
+	}
+	timeout, err := time.ParseDuration(h.Timeout)
+	if err != nil || timeout < 0 {
+		return DefaultHookTimeout
+	}
+	return timeout
 }
 
 // GetCommand returns a command by name; if not found, it returns an error
@@ -94,10 +245,59 @@ func (c *RepverConfig) GetParameterNames() ([]string, error) {
 	for group := range uniqueSet {
 		captureGroups = append(captureGroups, group)
 	}
+	sort.Strings(captureGroups)
 
 	return captureGroups, nil
 }
 
+// GetTemplateVariables returns the variables that can be resolved in the command's
+// branch name and commit message templates: the parameters, the named groups of
+// the params patterns, the built-in variables and the previous value of each group
+func (c *RepverCommand) GetTemplateVariables() (map[string]bool, error) {
+	variables := make(map[string]bool)
+
+	paramNames, err := c.GetParameterNames()
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range paramNames {
+		variables[name] = true
+	}
+
+	for _, param := range c.Params {
+		variables[param.Name] = true
+		re, err := regexp.Compile(param.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("failed to compile param pattern: %w", err)
+		}
+		for i, name := range re.SubexpNames() {
+			if i > 0 && name != "" {
+				variables[name] = true
+			}
+		}
+	}
+
+	for _, name := range BuiltinVariables {
+		variables[name] = true
+	}
+
+	for _, target := range c.Targets {
+		groups, err := target.GetGroupNames()
+		if err != nil {
+			return nil, err
+		}
+		for _, group := range groups {
+			if target.IsMatchOnly(group) {
+				continue
+			}
+			variables["old."+group] = true
+			variables["old."+target.GetBoundParamName(group)] = true
+		}
+	}
+
+	return variables, nil
+}
+
 // GetParameterNames returns a list of all unique parameter names
 func (c *RepverCommand) GetParameterNames() ([]string, error) {
 	uniqueSet := make(map[string]struct{})
@@ -115,12 +315,52 @@ func (c *RepverCommand) GetParameterNames() ([]string, error) {
 	for group := range uniqueSet {
 		captureGroups = append(captureGroups, group)
 	}
+	sort.Strings(captureGroups)
 
 	return captureGroups, nil
 }
 
 // GetParameterNames returns a list of all unique parameter names
+// Groups derived through Transforms and match-only groups are not parameters and are excluded
+// Bound groups are reported using the name of the parameter they are bound to
 func (t *RepverTarget) GetParameterNames() ([]string, error) {
+	groups, err := t.GetGroupNames()
+	if err != nil {
+		return nil, err
+	}
+	seen := make(map[string]bool)
+	paramNames := []string{}
+	for _, name := range groups {
+		if _, ok := t.Transforms[name]; ok {
+			continue
+		}
+		if t.IsMatchOnly(name) {
+			continue
+		}
+		paramName := t.GetBoundParamName(name)
+		if !seen[paramName] {
+			paramNames = append(paramNames, paramName)
+			seen[paramName] = true
+		}
+	}
+	return paramNames, nil
+}
+
+// GetBoundParamName returns the name of the parameter that supplies the value for a named group
+func (t *RepverTarget) GetBoundParamName(group string) string {
+	if paramName, ok := t.Bind[group]; ok {
+		return paramName
+	}
+	return group
+}
+
+// IsMatchOnly checks if a named group is a match-only group that is never replaced
+func (t *RepverTarget) IsMatchOnly(group string) bool {
+	return slices.Contains(t.MatchOnly, group)
+}
+
+// GetGroupNames returns the named capture groups in the target's pattern
+func (t *RepverTarget) GetGroupNames() ([]string, error) {
 	re, err := regexp.Compile(t.Pattern)
 	if err != nil {
 		return nil, fmt.Errorf("failed to compile regex: %s", err)
@@ -135,24 +375,222 @@ func (t *RepverTarget) GetParameterNames() ([]string, error) {
 	return captureGroups, nil
 }
 
-// BuildBranchName builds the branch name by replacing placeholders with values
-func (g *RepverGit) BuildBranchName(vals map[string]string) string {
-	branchName := g.BranchName
-	for key, val := range vals {
-		branchName = strings.ReplaceAll(branchName, "{{"+key+"}}", val)
+// BuildBranchName builds the branch name by rendering the branch_name template with values
+func (g *RepverGit) BuildBranchName(vals map[string]string) (string, error) {
+	return RenderTemplate(g.BranchName, vals)
+}
+
+// BuildCommitMessage builds the commit message by rendering the commit_message template with values,
+// followed by the rendered commit_body and trailers, each separated by a blank line
+func (g *RepverGit) BuildCommitMessage(vals map[string]string) (string, error) {
+	message, err := RenderTemplate(g.CommitMessage, vals)
+	if err != nil {
+		return "", err
+	}
+	paragraphs := []string{strings.TrimSpace(message)}
+
+	if g.CommitBody != "" {
+		body, err := RenderTemplate(g.CommitBody, vals)
+		if err != nil {
+			return "", err
+		}
+		if body = strings.TrimSpace(body); body != "" {
+			paragraphs = append(paragraphs, body)
+		}
 	}
 
-	return branchName
+	var trailers []string
+	for _, key := range g.trailerKeys() {
+		value, err := RenderTemplate(g.Trailers[key], vals)
+		if err != nil {
+			return "", err
+		}
+		trailers = append(trailers, fmt.Sprintf("%s: %s", key, strings.TrimSpace(value)))
+	}
+	if len(trailers) > 0 {
+		paragraphs = append(paragraphs, strings.Join(trailers, "\n"))
+	}
+
+	return strings.Join(paragraphs, "\n\n"), nil
 }
 
-// BuildCommitMessage builds the commit message by replacing placeholders with values
-func (g *RepverGit) BuildCommitMessage(vals map[string]string) string {
-	commitMessage := g.CommitMessage
-	for key, val := range vals {
-		commitMessage = strings.ReplaceAll(commitMessage, "{{"+key+"}}", val)
+// trailerKeys returns the keys of the trailers in the order they are written
+func (g *RepverGit) trailerKeys() []string {
+	keys := make([]string, 0, len(g.Trailers))
+	for key := range g.Trailers {
+		keys = append(keys, key)
 	}
+	sort.Strings(keys)
+	return keys
+}
 
-	return commitMessage
+// BuildTagName builds the tag name by rendering the tag_name template with values
+func (g *RepverGit) BuildTagName(vals map[string]string) (string, error) {
+	return RenderTemplate(g.TagName, vals)
+}
+
+// BuildTagMessage builds the tag message by rendering the tag_message template
+// with values, using the tag name if no message is configured
+func (g *RepverGit) BuildTagMessage(vals map[string]string) (string, error) {
+	if g.TagMessage == "" {
+		return g.BuildTagName(vals)
+	}
+	message, err := RenderTemplate(g.TagMessage, vals)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(message), nil
+}
+
+// SignFormat returns the gpg.format git uses to sign with the sign or tag_sign
+// value, or an empty string to use the format configured in git
+func SignFormat(sign string) string {
+	switch sign {
+	case "gpg":
+		return "openpgp"
+	case "ssh":
+		return "ssh"
+	}
+	return ""
+}
+
+// DescribeSteps returns the git and gh commands the options would run, in order.
+// Templates and values that are only known at run time are shown as written in
+// the configuration or as <placeholders>.
+func (g *RepverGit) DescribeSteps() []string {
+	var steps []string
+
+	switch g.DirtyTree {
+	case "stash":
+		steps = append(steps, "git stash push --include-untracked (if the workspace is not clean)")
+	case "worktree":
+		steps = append(steps, "git worktree add --detach <temporary directory> HEAD")
+	}
+	if g.CreateBranch && g.BaseBranch != "" {
+		if g.Fetch {
+			branch, _ := g.BaseRemoteBranch()
+			steps = append(steps, fmt.Sprintf("git fetch %s %s", g.RemoteName(), branch))
+		}
+		steps = append(steps, fmt.Sprintf("git checkout --no-track -b %s %s", g.BranchName, g.BaseBranch))
+	} else if g.CreateBranch {
+		steps = append(steps, fmt.Sprintf("git checkout -b %s", g.BranchName))
+	}
+	switch {
+	case g.CreateBranch && g.OnExistingBranch == "reuse":
+		steps = append(steps, fmt.Sprintf("git checkout %s (if the branch already exists)", g.BranchName))
+	case g.CreateBranch && g.OnExistingBranch == "recreate":
+		steps = append(steps, strings.TrimSpace(fmt.Sprintf("git checkout --no-track -B %s %s (if the branch already exists)", g.BranchName, g.BaseBranch)))
+	case g.CreateBranch && g.OnExistingBranch == "suffix":
+		steps = append(steps, fmt.Sprintf("git checkout -b %s-<n> (if the branch already exists)", g.BranchName))
+	}
+	if g.Commit {
+		steps = append(steps, "git add <modified target files>")
+		commit := []string{"git", "commit"}
+		if format := SignFormat(g.Sign); format != "" {
+			commit = []string{"git", "-c", "gpg.format=" + format, "commit"}
+		}
+		if g.Sign != "" {
+			commit = append(commit, "-S")
+		}
+		if g.Author != "" {
+			commit = append(commit, fmt.Sprintf("--author=%q", g.Author))
+		}
+		if g.Signoff {
+			commit = append(commit, "--signoff")
+		}
+		message := g.CommitMessage
+		if g.CommitBody != "" {
+			message += "\n\n" + strings.TrimSpace(g.CommitBody)
+		}
+		for i, key := range g.trailerKeys() {
+			separator := "\n"
+			if i == 0 {
+				separator = "\n\n"
+			}
+			message += separator + key + ": " + g.Trailers[key]
+		}
+		steps = append(steps, fmt.Sprintf("%s -m %q", strings.Join(commit, " "), message))
+		if g.Tag {
+			tag := []string{"git", "tag", "-a"}
+			if format := SignFormat(g.TagSign); format != "" {
+				tag = []string{"git", "-c", "gpg.format=" + format, "tag", "-s"}
+			} else if g.TagSign != "" {
+				tag = []string{"git", "tag", "-s"}
+			}
+			message := g.TagMessage
+			if message == "" {
+				message = g.TagName
+			}
+			steps = append(steps, fmt.Sprintf("%s %s -m %q", strings.Join(tag, " "), g.TagName, message))
+		}
+		if g.Push {
+			branch := "<current branch>"
+			if g.CreateBranch {
+				branch = g.BranchName
+			}
+			steps = append(steps, fmt.Sprintf("git push %s %s", g.Remote, branch))
+			if g.CreateBranch && g.OnExistingBranch == "recreate" {
+				steps = append(steps, fmt.Sprintf("git push --force-with-lease %s %s (if the branch was recreated)", g.Remote, branch))
+			}
+			if g.PullRequest == "GITHUB_CLI" && g.BaseBranch != "" {
+				branch, _ := g.BaseRemoteBranch()
+				steps = append(steps, fmt.Sprintf("gh pr create --fill --base %s", branch))
+			} else if g.PullRequest == "GITHUB_CLI" {
+				steps = append(steps, "gh pr create --fill")
+			}
+		}
+		if g.Tag && g.PushTags {
+			steps = append(steps, fmt.Sprintf("git push %s refs/tags/%s", g.Remote, g.TagName))
+		}
+	}
+	if g.DirtyTree == "worktree" {
+		steps = append(steps, "git worktree remove --force <temporary directory>")
+	} else if g.ReturnToOriginalBranch {
+		steps = append(steps, "git checkout <original branch>")
+	}
+	if g.ReturnToOriginalBranch && g.DeleteBranch && g.CreateBranch {
+		steps = append(steps, fmt.Sprintf("git branch -D %s", g.BranchName))
+	}
+	if g.DirtyTree == "stash" {
+		steps = append(steps, "git stash pop (if changes were stashed)")
+	}
+
+	return steps
+}
+
+// RemoteName returns the remote to push to and fetch from, defaulting to origin
+func (g *RepverGit) RemoteName() string {
+	if g.Remote == "" {
+		return "origin"
+	}
+	return g.Remote
+}
+
+// BaseRemoteBranch returns the name of the base branch on the remote, and
+// whether base_branch names the remote-tracking branch (origin/main) rather
+// than a local branch (main)
+func (g *RepverGit) BaseRemoteBranch() (string, bool) {
+	if branch, ok := strings.CutPrefix(g.BaseBranch, g.RemoteName()+"/"); ok {
+		return branch, true
+	}
+	return g.BaseBranch, false
+}
+
+// StepTimeout returns the time limit for the named step, or 0 if it has no limit.
+// Invalid durations are rejected by validation and fall back to the default here.
+func (g *RepverGit) StepTimeout(step string) time.Duration {
+	value, ok := g.Timeouts[step]
+	if !ok {
+		value = g.Timeout
+	}
+	if value == "" {
+		return DefaultGitTimeout
+	}
+	timeout, err := time.ParseDuration(value)
+	if err != nil || timeout < 0 {
+		return DefaultGitTimeout
+	}
+	return timeout
 }
 
 // GitOptionsSpecified checks if any Git options are specified
@@ -160,6 +598,83 @@ func (g *RepverGit) GitOptionsSpecified() bool {
 	return g.CreateBranch || g.DeleteBranch || g.Commit || g.Push || g.ReturnToOriginalBranch
 }
 
+// GetCurrentParamValue returns the value a parameter currently has in the target files
+// Only groups that receive the raw parameter value are considered, so targets using a
+// transform are skipped; if no value can be found, it returns an empty string
+func (c *RepverCommand) GetCurrentParamValue(name string) string {
+	for _, target := range c.Targets {
+		if target.Transform != "" {
+			continue
+		}
+		captured, err := target.Capture()
+		if err != nil {
+			Debugln("Failed to capture current values from %s: %v", target.Path, err)
+			continue
+		}
+		for _, line := range captured {
+			for group, value := range line.Values {
+				if target.IsMatchOnly(group) {
+					continue
+				}
+				if _, ok := target.Transforms[group]; ok {
+					continue
+				}
+				if target.GetBoundParamName(group) == name {
+					return value
+				}
+			}
+		}
+	}
+	return ""
+}
+
+// GetSourceTarget returns the target named by Source; if not found, it returns nil
+func (c *RepverCommand) GetSourceTarget() *RepverTarget {
+	if c.Source == "" {
+		return nil
+	}
+	for i := range c.Targets {
+		if c.Targets[i].Path == c.Source {
+			return &c.Targets[i]
+		}
+	}
+	return nil
+}
+
+// GetSourceValues reads the param values from the source target
+// Each group that is not match-only supplies the value of the param it is bound to
+// It returns an error if the source has no matching line or its lines disagree
+func (c *RepverCommand) GetSourceValues() (map[string]string, error) {
+	source := c.GetSourceTarget()
+	if source == nil {
+		return nil, fmt.Errorf("command %s has no source target", c.Name)
+	}
+
+	captured, err := source.Capture()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read source '%s': %w", source.Path, err)
+	}
+	if len(captured) == 0 {
+		return nil, fmt.Errorf("source '%s' has no line matching pattern '%s'", source.Path, source.Pattern)
+	}
+
+	values := make(map[string]string)
+	for _, line := range captured {
+		for group, value := range line.Values {
+			if source.IsMatchOnly(group) {
+				continue
+			}
+			paramName := source.GetBoundParamName(group)
+			if existing, ok := values[paramName]; ok && existing != value {
+				return nil, fmt.Errorf("source '%s' has different values for '%s': %s and %s", source.Path, paramName, existing, value)
+			}
+			values[paramName] = value
+		}
+	}
+
+	return values, nil
+}
+
 // GetParam returns a param definition by name; if not found, it returns nil
 func (c *RepverCommand) GetParam(name string) *RepverParam {
 	for i := range c.Params {
@@ -208,38 +723,22 @@ func (p *RepverParam) ValidateValue(value string) error {
 	return nil
 }
 
-// ApplyTransform applies the transform template using extracted named groups
-// If transform is empty, returns the original value unchanged
-func ApplyTransform(transform string, extractedGroups map[string]string) string {
-	if transform == "" {
-		return ""
-	}
-
-	result := transform
-	for key, val := range extractedGroups {
-		result = strings.ReplaceAll(result, "{{"+key+"}}", val)
-	}
-
-	return result
+// ApplyTransform renders the transform template using extracted named groups
+// If transform is empty, returns an empty string
+func ApplyTransform(transform string, extractedGroups map[string]string) (string, error) {
+	return RenderTemplate(transform, extractedGroups)
 }
 
-// GetTransformParamName extracts the param name that is referenced in a transform template
-// Returns the first param name found in the transform, or empty string if none found
+// GetTransformParamNames returns the variable names referenced in the transform template
+// Returns nil if no transform is specified or the template cannot be parsed
 func (t *RepverTarget) GetTransformParamNames() []string {
 	if t.Transform == "" {
 		return nil
 	}
 
-	// Find all {{name}} patterns in the transform
-	matches := placeholderRegex.FindAllStringSubmatch(t.Transform, -1)
-
-	var names []string
-	seen := make(map[string]bool)
-	for _, match := range matches {
-		if len(match) > 1 && !seen[match[1]] {
-			names = append(names, match[1])
-			seen[match[1]] = true
-		}
+	names, err := TemplateVariables(t.Transform)
+	if err != nil {
+		return nil
 	}
 
 	return names
@@ -0,0 +1,120 @@
+package repver
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/UnitVectorY-Labs/repver/internal/color"
+)
+
+// noNewlineMarker follows the last line of a diff hunk when the file does not end with a newline
+const noNewlineMarker = `\ No newline at end of file`
+
+// UnifiedDiff returns the planned changes as a git-style unified diff with the
+// given number of context lines around each change. Plans that modify nothing
+// return an empty string.
+func (p *ExecutionPlan) UnifiedDiff(context int) string {
+	if p == nil || !p.Modified || len(p.Changes) == 0 {
+		return ""
+	}
+	if context < 0 {
+		context = 0
+	}
+
+	oldLines := splitDiffLines(p.OriginalContent)
+	newLines := splitDiffLines(p.ModifiedContent)
+	noFinalNewline := !strings.HasSuffix(p.OriginalContent, "\n")
+
+	changed := make(map[int]bool)
+	for _, change := range p.Changes {
+		changed[change.LineNumber-1] = true
+	}
+
+	path := filepath.ToSlash(filepath.Clean(p.Path))
+	var diff strings.Builder
+	fmt.Fprintf(&diff, "diff --git a/%s b/%s\n", path, path)
+	fmt.Fprintf(&diff, "--- a/%s\n", path)
+	fmt.Fprintf(&diff, "+++ b/%s\n", path)
+
+	// Changes replace lines one for one, so both sides of every hunk share the
+	// same line range. Hunks whose context overlaps or touches are merged.
+	for i := 0; i < len(p.Changes); {
+		start := max(p.Changes[i].LineNumber-1-context, 0)
+		end := min(p.Changes[i].LineNumber-1+context, len(oldLines)-1)
+		j := i + 1
+		for j < len(p.Changes) && p.Changes[j].LineNumber-1-context <= end+1 {
+			end = min(p.Changes[j].LineNumber-1+context, len(oldLines)-1)
+			j++
+		}
+
+		fmt.Fprintf(&diff, "@@ -%d,%d +%d,%d @@\n", start+1, end-start+1, start+1, end-start+1)
+		writeLine := func(prefix string, line string, index int) {
+			diff.WriteString(prefix + line + "\n")
+			if noFinalNewline && index == len(oldLines)-1 {
+				diff.WriteString(noNewlineMarker + "\n")
+			}
+		}
+		for line := start; line <= end; {
+			if !changed[line] {
+				writeLine(" ", oldLines[line], line)
+				line++
+				continue
+			}
+			// Group consecutive changed lines so all removals precede the additions
+			run := line
+			for run <= end && changed[run] {
+				run++
+			}
+			for k := line; k < run; k++ {
+				writeLine("-", oldLines[k], k)
+			}
+			for k := line; k < run; k++ {
+				writeLine("+", newLines[k], k)
+			}
+			line = run
+		}
+
+		i = j
+	}
+
+	return diff.String()
+}
+
+// BuildPatch concatenates the unified diffs of every plan into a patch that
+// can be applied with git apply.
+func BuildPatch(plans []*ExecutionPlan, context int) string {
+	var patch strings.Builder
+	for _, plan := range plans {
+		patch.WriteString(plan.UnifiedDiff(context))
+	}
+	return patch.String()
+}
+
+// ColorizeDiff colors the lines of a unified diff for terminal output
+func ColorizeDiff(diff string) string {
+	lines := strings.SplitAfter(diff, "\n")
+	for i, line := range lines {
+		text := strings.TrimSuffix(line, "\n")
+		newline := line[len(text):]
+		switch {
+		case strings.HasPrefix(text, "diff "), strings.HasPrefix(text, "--- "), strings.HasPrefix(text, "+++ "):
+			lines[i] = color.Bold(text) + newline
+		case strings.HasPrefix(text, "@@"):
+			lines[i] = color.Cyan(text) + newline
+		case strings.HasPrefix(text, "-"):
+			lines[i] = color.Red(text) + newline
+		case strings.HasPrefix(text, "+"):
+			lines[i] = color.Green(text) + newline
+		}
+	}
+	return strings.Join(lines, "")
+}
+
+// splitDiffLines splits file content into lines without their line endings
+func splitDiffLines(content string) []string {
+	if content == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(content, "\n"), "\n")
+}
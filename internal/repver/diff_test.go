@@ -0,0 +1,101 @@
+package repver
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestUnifiedDiff(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		context  int
+		expected string
+	}{
+		{
+			name:    "single change with context",
+			content: "a\nb\nversion: 1.0.0\nc\nd\ne\n",
+			context: 1,
+			expected: "diff --git a/version.txt b/version.txt\n" +
+				"--- a/version.txt\n" +
+				"+++ b/version.txt\n" +
+				"@@ -2,3 +2,3 @@\n" +
+				" b\n" +
+				"-version: 1.0.0\n" +
+				"+version: 2.0.0\n" +
+				" c\n",
+		},
+		{
+			name:    "nearby changes share a hunk",
+			content: "version: 1.0.0\nx\nversion: 1.0.0\ny\nz\nw\nversion: 1.0.0\n",
+			context: 1,
+			expected: "diff --git a/version.txt b/version.txt\n" +
+				"--- a/version.txt\n" +
+				"+++ b/version.txt\n" +
+				"@@ -1,4 +1,4 @@\n" +
+				"-version: 1.0.0\n" +
+				"+version: 2.0.0\n" +
+				" x\n" +
+				"-version: 1.0.0\n" +
+				"+version: 2.0.0\n" +
+				" y\n" +
+				"@@ -6,2 +6,2 @@\n" +
+				" w\n" +
+				"-version: 1.0.0\n" +
+				"+version: 2.0.0\n",
+		},
+		{
+			name:    "consecutive changes are grouped",
+			content: "version: 1.0.0\nversion: 1.0.0\n",
+			context: 3,
+			expected: "diff --git a/version.txt b/version.txt\n" +
+				"--- a/version.txt\n" +
+				"+++ b/version.txt\n" +
+				"@@ -1,2 +1,2 @@\n" +
+				"-version: 1.0.0\n" +
+				"-version: 1.0.0\n" +
+				"+version: 2.0.0\n" +
+				"+version: 2.0.0\n",
+		},
+		{
+			name:    "no newline at end of file",
+			content: "a\nversion: 1.0.0",
+			context: 0,
+			expected: "diff --git a/version.txt b/version.txt\n" +
+				"--- a/version.txt\n" +
+				"+++ b/version.txt\n" +
+				"@@ -2,1 +2,1 @@\n" +
+				"-version: 1.0.0\n" +
+				"\\ No newline at end of file\n" +
+				"+version: 2.0.0\n" +
+				"\\ No newline at end of file\n",
+		},
+		{
+			name:     "no changes",
+			content:  "version: 2.0.0\n",
+			context:  3,
+			expected: "",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tmpDir := t.TempDir()
+			t.Chdir(tmpDir)
+			if err := os.WriteFile(filepath.Join(tmpDir, "version.txt"), []byte(tc.content), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			target := RepverTarget{Path: "version.txt", Pattern: `^version: (?P<version>.*)$`}
+			plan, err := target.Plan(map[string]string{"version": "2.0.0"}, nil)
+			if err != nil {
+				t.Fatalf("Plan returned error: %v", err)
+			}
+
+			if got := plan.UnifiedDiff(tc.context); got != tc.expected {
+				t.Errorf("unexpected diff:\n%s\nwant:\n%s", got, tc.expected)
+			}
+		})
+	}
+}
@@ -7,22 +7,85 @@ import (
 	"maps"
 	"os"
 	"regexp"
+	"slices"
 	"strings"
 
 	"github.com/UnitVectorY-Labs/repver/internal/color"
 )
 
 type FileChange struct {
-	LineNumber int
-	OldLine    string
-	NewLine    string
+	LineNumber int    `json:"line_number"`
+	OldLine    string `json:"old_line"`
+	NewLine    string `json:"new_line"`
+	// OldValues maps each replaced named group to the text it captured before the change
+	OldValues map[string]string `json:"old_values"`
+	// NewValues maps each replaced named group to the text written in its place
+	NewValues map[string]string `json:"new_values"`
 }
 
 type ExecutionPlan struct {
-	Path            string
-	Modified        bool
-	ModifiedContent string
-	Changes         []FileChange
+	Path            string       `json:"path"`
+	Modified        bool         `json:"modified"`
+	OriginalContent string       `json:"-"`
+	ModifiedContent string       `json:"-"`
+	Changes         []FileChange `json:"changes"`
+	// OldValues maps each replaced group, and the param it is bound to, to the
+	// distinct values it held before the change in the order they were found,
+	// including groups whose value stayed the same
+	OldValues map[string][]string `json:"old_values"`
+}
+
+// CapturedLine records the values captured by a target's named groups on a matching line
+type CapturedLine struct {
+	LineNumber int
+	Line       string
+	Values     map[string]string
+}
+
+// Capture returns the values currently captured by the target's named groups
+// on every matching line without modifying anything.
+func (t *RepverTarget) Capture() ([]CapturedLine, error) {
+	content, err := os.ReadFile(t.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	re, err := regexp.Compile(t.Pattern)
+	if err != nil {
+		return nil, err
+	}
+	names := re.SubexpNames()
+
+	var captured []CapturedLine
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		line := scanner.Text()
+
+		matches := re.FindStringSubmatch(line)
+		if matches == nil {
+			continue
+		}
+
+		values := make(map[string]string)
+		for i, name := range names {
+			if i > 0 && name != "" {
+				values[name] = matches[i]
+			}
+		}
+		captured = append(captured, CapturedLine{
+			LineNumber: lineNum,
+			Line:       line,
+			Values:     values,
+		})
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return captured, nil
 }
 
 // Plan computes the file changes for a target without writing anything to disk.
@@ -55,21 +118,29 @@ func (t *RepverTarget) Plan(values map[string]string, extractedGroups map[string
 		}
 	}
 
-	// Prepare effective values: apply transforms if specified
+	// Prepare effective values: resolve each group's bound param, then apply transforms if specified
 	effectiveValues := make(map[string]string)
 	maps.Copy(effectiveValues, values)
+	for group, paramName := range t.Bind {
+		if value, ok := values[paramName]; ok {
+			effectiveValues[group] = value
+		} else {
+			delete(effectiveValues, group)
+		}
+	}
 
-	// If transform is specified, apply it to get the replacement value
-	if t.Transform != "" && extractedGroups != nil {
-		transformedValue := ApplyTransform(t.Transform, extractedGroups)
-		Debugln("Transform applied: '%s' -> '%s'", t.Transform, transformedValue)
-
-		// The transformed value replaces all named capture groups in this target
-		// since transform produces a single output value for the entire replacement
-		for i, name := range names {
-			if i > 0 && name != "" {
-				effectiveValues[name] = transformedValue
-			}
+	// Determine which groups receive a transformed value. A single transform
+	// replaces all named capture groups in this target since it produces a
+	// single output value, while per-group transforms each render their own.
+	groupTransforms := make(map[string]string)
+	for i, name := range names {
+		if i == 0 || name == "" || t.IsMatchOnly(name) {
+			continue
+		}
+		if transform, ok := t.Transforms[name]; ok {
+			groupTransforms[name] = transform
+		} else if t.Transform != "" && extractedGroups != nil {
+			groupTransforms[name] = t.Transform
 		}
 	}
 
@@ -79,7 +150,8 @@ func (t *RepverTarget) Plan(values map[string]string, extractedGroups map[string
 	var modifiedLines []string
 	lineNum := 0
 	matchesFound := 0
-	var changes []FileChange
+	changes := []FileChange{}
+	planOldValues := make(map[string][]string)
 
 	for scanner.Scan() {
 		lineNum++
@@ -94,15 +166,46 @@ func (t *RepverTarget) Plan(values map[string]string, extractedGroups map[string
 			if len(matches) > 1 { // At least one capture group
 				// Start with the original line
 				modifiedLine := line
+				oldValues := make(map[string]string)
+				newValues := make(map[string]string)
+
+				// Render transforms for this line, exposing the captured text of
+				// every group in the line as {{old.<name>}}
+				lineValues := effectiveValues
+				if len(groupTransforms) > 0 {
+					lineValues = maps.Clone(effectiveValues)
+					transformValues := maps.Clone(extractedGroups)
+					if transformValues == nil {
+						transformValues = make(map[string]string)
+					}
+					for i, name := range names {
+						if i > 0 && name != "" {
+							transformValues["old."+name] = matches[i]
+							transformValues["old."+t.GetBoundParamName(name)] = matches[i]
+						}
+					}
+					for name, transform := range groupTransforms {
+						transformedValue, err := ApplyTransform(transform, transformValues)
+						if err != nil {
+							Debugln("Failed to apply transform for group '%s': %v", name, err)
+							return nil, err
+						}
+						Debugln("Transform applied to group '%s': '%s' -> '%s'", name, transform, transformedValue)
+						lineValues[name] = transformedValue
+					}
+				}
 
 				// Process each named capture group
 				for i, name := range names {
 					if i == 0 || name == "" {
 						continue // Skip the full match and unnamed groups
 					}
+					if t.IsMatchOnly(name) {
+						continue // Match-only groups are never replaced
+					}
 
 					// Check if we have a replacement value for this named group
-					replacement, exists := effectiveValues[name]
+					replacement, exists := lineValues[name]
 					if !exists {
 						Debugln("Missing replacement value for group '%s'", name)
 						return nil, fmt.Errorf("no replacement value for named group '%s'", name)
@@ -120,6 +223,15 @@ func (t *RepverTarget) Plan(values map[string]string, extractedGroups map[string
 
 						// Replace just this capture group
 						modifiedLine = modifiedLine[:start] + replacement + modifiedLine[end:]
+						for _, key := range []string{name, t.GetBoundParamName(name)} {
+							if !slices.Contains(planOldValues[key], capturedText) {
+								planOldValues[key] = append(planOldValues[key], capturedText)
+							}
+						}
+						if capturedText != replacement {
+							oldValues[name] = capturedText
+							newValues[name] = replacement
+						}
 					}
 				}
 
@@ -130,6 +242,8 @@ func (t *RepverTarget) Plan(values map[string]string, extractedGroups map[string
 						LineNumber: lineNum,
 						OldLine:    line,
 						NewLine:    modifiedLine,
+						OldValues:  oldValues,
+						NewValues:  newValues,
 					})
 				}
 
@@ -167,8 +281,10 @@ func (t *RepverTarget) Plan(values map[string]string, extractedGroups map[string
 	plan := &ExecutionPlan{
 		Path:            t.Path,
 		Modified:        string(content) != modifiedContent,
+		OriginalContent: string(content),
 		ModifiedContent: modifiedContent,
 		Changes:         changes,
+		OldValues:       planOldValues,
 	}
 	if !plan.Modified {
 		Debugln("No changes were made to the file content")
@@ -179,6 +295,50 @@ func (t *RepverTarget) Plan(values map[string]string, extractedGroups map[string
 	return plan, nil
 }
 
+// OldTemplateValues collects the previous values recorded in the plans as
+// template values keyed by "old.<name>". When different files held different
+// values for the same name, all distinct values are listed separated by ", ".
+func OldTemplateValues(plans []*ExecutionPlan) map[string]string {
+	collected := make(map[string][]string)
+	for _, plan := range plans {
+		if plan == nil {
+			continue
+		}
+		for key, values := range plan.OldValues {
+			for _, value := range values {
+				if !slices.Contains(collected[key], value) {
+					collected[key] = append(collected[key], value)
+				}
+			}
+		}
+	}
+
+	result := make(map[string]string)
+	for key, values := range collected {
+		result["old."+key] = strings.Join(values, ", ")
+	}
+	return result
+}
+
+// PrintChanges prints the planned changes, as a unified diff when --diff is used
+func (p *ExecutionPlan) PrintChanges() {
+	if !p.Modified {
+		return
+	}
+	if Diff {
+		fmt.Print(ColorizeDiff(p.UnifiedDiff(DiffContext)))
+	} else {
+		fmt.Println(color.Bold("\nFILE CHANGES:"))
+		fmt.Printf("  %s %s\n", color.Bold("File:"), color.Cyan(p.Path))
+		for _, change := range p.Changes {
+			fmt.Printf("  %s\n", color.Boldf("+- Line %d:", change.LineNumber))
+			fmt.Printf("  |  %s\n", color.Red("- "+change.OldLine))
+			fmt.Printf("  |  %s\n", color.Green("+ "+change.NewLine))
+		}
+		fmt.Println("  +-")
+	}
+}
+
 // ExecutePlan applies a previously computed execution plan.
 func (t *RepverTarget) ExecutePlan(plan *ExecutionPlan) (bool, error) {
 	if plan == nil {
@@ -188,14 +348,7 @@ func (t *RepverTarget) ExecutePlan(plan *ExecutionPlan) (bool, error) {
 		return false, nil
 	}
 
-	fmt.Println(color.Bold("\nFILE CHANGES:"))
-	fmt.Printf("  %s %s\n", color.Bold("File:"), color.Cyan(plan.Path))
-	for _, change := range plan.Changes {
-		fmt.Printf("  %s\n", color.Boldf("+- Line %d:", change.LineNumber))
-		fmt.Printf("  |  %s\n", color.Red("- "+change.OldLine))
-		fmt.Printf("  |  %s\n", color.Green("+ "+change.NewLine))
-	}
-	fmt.Println("  +-")
+	plan.PrintChanges()
 
 	if DryRun {
 		Debugln("Dry run mode enabled, skipping file write")
@@ -45,3 +45,157 @@ func TestExecutionPlanNoOp(t *testing.T) {
 		t.Fatalf("file was modified unexpectedly: %q", string(content))
 	}
 }
+
+func TestPlanWithGroupTransforms(t *testing.T) {
+	tmpDir := t.TempDir()
+	targetPath := filepath.Join(tmpDir, "Dockerfile")
+	if err := os.WriteFile(targetPath, []byte("FROM golang:1.22.3-alpine3.19\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	target := RepverTarget{
+		Path:    targetPath,
+		Pattern: `^FROM golang:(?P<ver>.*)-alpine(?P<alpine>.*)$`,
+		Transforms: map[string]string{
+			"ver":    "{{major}}.{{minor}}.{{patch}}",
+			"alpine": "{{alpine}}",
+		},
+	}
+
+	groups := map[string]string{"major": "1", "minor": "23", "patch": "0", "alpine": "3.20"}
+	plan, err := target.Plan(map[string]string{}, groups)
+	if err != nil {
+		t.Fatalf("Plan returned error: %v", err)
+	}
+	if !plan.Modified {
+		t.Fatal("expected changes to be planned")
+	}
+
+	want := "FROM golang:1.23.0-alpine3.20\n"
+	if plan.ModifiedContent != want {
+		t.Fatalf("expected %q, got %q", want, plan.ModifiedContent)
+	}
+}
+
+func TestPlanWithBindAndMatchOnly(t *testing.T) {
+	tmpDir := t.TempDir()
+	targetPath := filepath.Join(tmpDir, "tools.txt")
+	content := "go 1.22.3 # linux\nnode 20.1.0 # linux\n"
+	if err := os.WriteFile(targetPath, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	goTarget := RepverTarget{
+		Path:      targetPath,
+		Pattern:   `^go (?P<version>\S+) # (?P<os>.*)$`,
+		Bind:      map[string]string{"version": "goversion"},
+		MatchOnly: []string{"os"},
+	}
+
+	names, err := goTarget.GetParameterNames()
+	if err != nil {
+		t.Fatalf("GetParameterNames returned error: %v", err)
+	}
+	if len(names) != 1 || names[0] != "goversion" {
+		t.Fatalf("expected parameter names [goversion], got %v", names)
+	}
+
+	plan, err := goTarget.Plan(map[string]string{"goversion": "1.23.0", "version": "9.9.9"}, nil)
+	if err != nil {
+		t.Fatalf("Plan returned error: %v", err)
+	}
+
+	want := "go 1.23.0 # linux\nnode 20.1.0 # linux\n"
+	if plan.ModifiedContent != want {
+		t.Fatalf("expected %q, got %q", want, plan.ModifiedContent)
+	}
+	if len(plan.Changes) != 1 {
+		t.Fatalf("expected 1 change, got %d", len(plan.Changes))
+	}
+	if _, ok := plan.Changes[0].OldValues["os"]; ok {
+		t.Fatal("expected match-only group to be left untouched")
+	}
+}
+
+func TestPlanRecordsOldValues(t *testing.T) {
+	tmpDir := t.TempDir()
+	goMod := filepath.Join(tmpDir, "go.mod")
+	workflow := filepath.Join(tmpDir, "build.yml")
+	if err := os.WriteFile(goMod, []byte("go 1.22.3\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(workflow, []byte("go-version: 1.22.1\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	targets := []RepverTarget{
+		{Path: goMod, Pattern: `^go (?P<version>.*)$`},
+		{Path: workflow, Pattern: `^go-version: (?P<ver>.*)$`, Transform: "{{major}}.{{minor}}.0 # was {{old.ver}}"},
+	}
+	values := map[string]string{"version": "1.23.0"}
+	groups := map[string]string{"major": "1", "minor": "23"}
+
+	var plans []*ExecutionPlan
+	for _, target := range targets {
+		plan, err := target.Plan(values, groups)
+		if err != nil {
+			t.Fatalf("Plan returned error: %v", err)
+		}
+		plans = append(plans, plan)
+	}
+
+	if got := plans[1].ModifiedContent; got != "go-version: 1.23.0 # was 1.22.1\n" {
+		t.Fatalf("unexpected transformed content: %q", got)
+	}
+	if got := plans[0].Changes[0].OldValues["version"]; got != "1.22.3" {
+		t.Fatalf("expected old value 1.22.3, got %q", got)
+	}
+
+	old := OldTemplateValues(plans)
+	if old["old.version"] != "1.22.3" {
+		t.Errorf("expected old.version to be 1.22.3, got %q", old["old.version"])
+	}
+	if old["old.ver"] != "1.22.1" {
+		t.Errorf("expected old.ver to be 1.22.1, got %q", old["old.ver"])
+	}
+}
+
+func TestPlanRecordsOldValuesOfUnchangedGroups(t *testing.T) {
+	tmpDir := t.TempDir()
+	goMod := filepath.Join(tmpDir, "go.mod")
+	if err := os.WriteFile(goMod, []byte("go 1.22\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	target := RepverTarget{Path: goMod, Pattern: `^go (?P<major>\d+)\.(?P<minor>\d+)$`}
+	plan, err := target.Plan(map[string]string{"major": "1", "minor": "23"}, nil)
+	if err != nil {
+		t.Fatalf("Plan returned error: %v", err)
+	}
+	if _, ok := plan.Changes[0].OldValues["major"]; ok {
+		t.Errorf("expected the unchanged group not to be listed as a change")
+	}
+
+	old := OldTemplateValues([]*ExecutionPlan{plan})
+	rendered, err := RenderTemplate("Update Go from {{old.major}}.{{old.minor}} to {{major}}.{{minor}}",
+		map[string]string{"major": "1", "minor": "23", "old.major": old["old.major"], "old.minor": old["old.minor"]})
+	if err != nil {
+		t.Fatalf("RenderTemplate returned error: %v", err)
+	}
+	if rendered != "Update Go from 1.22 to 1.23" {
+		t.Errorf("unexpected message: %q", rendered)
+	}
+}
+
+func TestOldTemplateValuesListsDistinctValues(t *testing.T) {
+	plans := []*ExecutionPlan{
+		{OldValues: map[string][]string{"version": {"1.21.0"}}},
+		{OldValues: map[string][]string{"version": {"1.22.0", "1.21.0"}}},
+		nil,
+	}
+
+	old := OldTemplateValues(plans)
+	if old["old.version"] != "1.21.0, 1.22.0" {
+		t.Errorf("expected all distinct old values, got %q", old["old.version"])
+	}
+}
@@ -0,0 +1,199 @@
+package repver
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Supported values for the monotonic command option
+const (
+	MonotonicNone    = ""
+	MonotonicSemver  = "semver"
+	MonotonicNumeric = "numeric"
+	MonotonicLexical = "lexical"
+)
+
+// Pre-compiled regex pattern for lenient semantic version parsing
+var semverValueRegex = regexp.MustCompile(`^v?(\d+)(?:\.(\d+))?(?:\.(\d+))?(?:-([0-9A-Za-z.-]+))?(?:\+[0-9A-Za-z.-]+)?$`)
+
+// validateMonotonic checks if the monotonic policy is one of the supported values.
+func validateMonotonic(policy string) error {
+	switch policy {
+	case MonotonicNone, MonotonicSemver, MonotonicNumeric, MonotonicLexical:
+		return nil
+	}
+	return fmt.Errorf("invalid monotonic value: %s (must be semver, numeric or lexical)", policy)
+}
+
+// CompareValues compares two values using the given monotonic policy.
+// It returns -1 if a is lower than b, 0 if they are equal and 1 if a is higher than b.
+func CompareValues(policy string, a string, b string) (int, error) {
+	switch policy {
+	case MonotonicSemver:
+		return compareSemver(a, b)
+	case MonotonicNumeric:
+		return compareNumeric(a, b)
+	case MonotonicLexical:
+		return strings.Compare(a, b), nil
+	}
+	return 0, fmt.Errorf("unsupported monotonic policy: %s", policy)
+}
+
+// compareNumeric compares two values as floating point numbers.
+func compareNumeric(a string, b string) (int, error) {
+	x, err := strconv.ParseFloat(a, 64)
+	if err != nil {
+		return 0, fmt.Errorf("value '%s' is not numeric", a)
+	}
+	y, err := strconv.ParseFloat(b, 64)
+	if err != nil {
+		return 0, fmt.Errorf("value '%s' is not numeric", b)
+	}
+
+	switch {
+	case x < y:
+		return -1, nil
+	case x > y:
+		return 1, nil
+	}
+	return 0, nil
+}
+
+// compareSemver compares two values as semantic versions. Missing minor and
+// patch components are treated as zero so that values like 1.22 can be
+// compared with 1.22.3, and a leading "v" is ignored.
+func compareSemver(a string, b string) (int, error) {
+	x := semverValueRegex.FindStringSubmatch(a)
+	if x == nil {
+		return 0, fmt.Errorf("value '%s' is not a semantic version", a)
+	}
+	y := semverValueRegex.FindStringSubmatch(b)
+	if y == nil {
+		return 0, fmt.Errorf("value '%s' is not a semantic version", b)
+	}
+
+	for i := 1; i <= 3; i++ {
+		if c := compareNumericIdentifier(x[i], y[i]); c != 0 {
+			return c, nil
+		}
+	}
+
+	return comparePrerelease(x[4], y[4]), nil
+}
+
+// compareNumericIdentifier compares two digit strings, treating empty as zero.
+func compareNumericIdentifier(a string, b string) int {
+	a = strings.TrimLeft(a, "0")
+	b = strings.TrimLeft(b, "0")
+	if len(a) != len(b) {
+		if len(a) < len(b) {
+			return -1
+		}
+		return 1
+	}
+	return strings.Compare(a, b)
+}
+
+// comparePrerelease compares two prerelease strings following semver precedence,
+// where a version without a prerelease is higher than one with a prerelease.
+func comparePrerelease(a string, b string) int {
+	if a == b {
+		return 0
+	}
+	if a == "" {
+		return 1
+	}
+	if b == "" {
+		return -1
+	}
+
+	x := strings.Split(a, ".")
+	y := strings.Split(b, ".")
+	for i := 0; i < len(x) && i < len(y); i++ {
+		xNum := isDigits(x[i])
+		yNum := isDigits(y[i])
+		var c int
+		switch {
+		case xNum && yNum:
+			c = compareNumericIdentifier(x[i], y[i])
+		case xNum:
+			c = -1
+		case yNum:
+			c = 1
+		default:
+			c = strings.Compare(x[i], y[i])
+		}
+		if c != 0 {
+			return c
+		}
+	}
+
+	switch {
+	case len(x) < len(y):
+		return -1
+	case len(x) > len(y):
+		return 1
+	}
+	return 0
+}
+
+// isDigits checks if a string is made up entirely of ASCII digits.
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// CheckMonotonic verifies that none of the planned changes lower a value
+// according to the command's monotonic policy. The returned error lists every
+// offending file and line. Groups whose values cannot be compared under the
+// policy, such as a tag with an "-alpine" suffix, are not downgrades; they are
+// skipped and returned so they can be reported as warnings.
+func (c *RepverCommand) CheckMonotonic(plans []*ExecutionPlan) ([]string, error) {
+	if c.Monotonic == MonotonicNone {
+		return nil, nil
+	}
+
+	var skipped []string
+	var offenses []string
+	for _, plan := range plans {
+		if plan == nil {
+			continue
+		}
+		for _, change := range plan.Changes {
+			names := make([]string, 0, len(change.OldValues))
+			for name := range change.OldValues {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+
+			for _, name := range names {
+				oldValue := change.OldValues[name]
+				newValue := change.NewValues[name]
+				cmp, err := CompareValues(c.Monotonic, newValue, oldValue)
+				if err != nil {
+					skipped = append(skipped, fmt.Sprintf("%s:%d group '%s' not checked for a downgrade: %s", plan.Path, change.LineNumber, name, err))
+					continue
+				}
+				if cmp < 0 {
+					offenses = append(offenses, fmt.Sprintf("  %s:%d group '%s': %s -> %s", plan.Path, change.LineNumber, name, oldValue, newValue))
+				}
+			}
+		}
+	}
+
+	if len(offenses) > 0 {
+		return skipped, fmt.Errorf("the following changes would downgrade values (%s):\n%s", c.Monotonic, strings.Join(offenses, "\n"))
+	}
+
+	return skipped, nil
+}
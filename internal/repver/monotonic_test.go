@@ -0,0 +1,104 @@
+package repver
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCompareValues(t *testing.T) {
+	tests := []struct {
+		name        string
+		policy      string
+		a           string
+		b           string
+		expected    int
+		shouldError bool
+	}{
+		{"semver lower patch", MonotonicSemver, "1.21.0", "1.22.3", -1, false},
+		{"semver equal", MonotonicSemver, "1.22.3", "1.22.3", 0, false},
+		{"semver higher minor", MonotonicSemver, "1.23.0", "1.22.3", 1, false},
+		{"semver numeric not lexical", MonotonicSemver, "1.10.0", "1.9.0", 1, false},
+		{"semver missing patch", MonotonicSemver, "1.22", "1.22.0", 0, false},
+		{"semver with v prefix", MonotonicSemver, "v2.0.0", "1.9.9", 1, false},
+		{"semver prerelease lower", MonotonicSemver, "1.0.0-rc.1", "1.0.0", -1, false},
+		{"semver prerelease numeric", MonotonicSemver, "1.0.0-rc.2", "1.0.0-rc.10", -1, false},
+		{"semver invalid", MonotonicSemver, "latest", "1.0.0", 0, true},
+		{"numeric lower", MonotonicNumeric, "9", "10", -1, false},
+		{"numeric decimal", MonotonicNumeric, "1.5", "1.25", 1, false},
+		{"numeric invalid", MonotonicNumeric, "abc", "1", 0, true},
+		{"lexical lower", MonotonicLexical, "10", "9", -1, false},
+		{"unknown policy", "other", "1", "2", 0, true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := CompareValues(tc.policy, tc.a, tc.b)
+			if tc.shouldError {
+				if err == nil {
+					t.Errorf("expected error but got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tc.expected {
+				t.Errorf("CompareValues(%q, %q, %q) = %d, want %d", tc.policy, tc.a, tc.b, got, tc.expected)
+			}
+		})
+	}
+}
+
+func TestCheckMonotonic(t *testing.T) {
+	tmpDir := t.TempDir()
+	targetPath := filepath.Join(tmpDir, "version.txt")
+	if err := os.WriteFile(targetPath, []byte("version: 1.22.3\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	command := RepverCommand{
+		Name:      "test",
+		Monotonic: MonotonicSemver,
+		Targets: []RepverTarget{
+			{Path: targetPath, Pattern: `^version: (?P<version>.*)$`},
+		},
+	}
+
+	tests := []struct {
+		name        string
+		value       string
+		shouldError bool
+		skipped     bool
+	}{
+		{"upgrade", "1.23.0", false, false},
+		{"same", "1.22.3", false, false},
+		{"downgrade", "1.21.0", true, false},
+		{"not comparable", "latest", false, true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			plan, err := command.Targets[0].Plan(map[string]string{"version": tc.value}, nil)
+			if err != nil {
+				t.Fatalf("Plan returned error: %v", err)
+			}
+
+			skipped, err := command.CheckMonotonic([]*ExecutionPlan{plan})
+			if tc.skipped != (len(skipped) > 0) {
+				t.Errorf("expected skipped: %v, got %v", tc.skipped, skipped)
+			}
+			if tc.shouldError {
+				if err == nil {
+					t.Fatal("expected downgrade error but got none")
+				}
+				if !strings.Contains(err.Error(), targetPath+":1") {
+					t.Errorf("expected error to reference the offending line, got: %v", err)
+				}
+			} else if err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
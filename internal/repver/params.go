@@ -4,6 +4,7 @@ import (
 	"flag"
 	"fmt"
 	"os"
+	"strings"
 )
 
 var Debug bool
@@ -11,22 +12,131 @@ var DryRun bool
 var NoColor bool
 var UserCommand string
 var Exists bool
+var AllowDowngrade bool
+var NoInput bool
+var Help bool
+var Check bool
+var Format string
+var Output string
+var Diff bool
+var DiffContext int
+var PatchOut string
+var PlanOut string
 
-// ParseParams initializes the command-line flags and sets the global variables
-func ParseParams() {
-	debug := flag.Bool("debug", false, "Enable debug mode")
-	command := flag.String("command", "", "Command to execute")
-	dryRun := flag.Bool("dry-run", false, "Dry run mode - shows changes without applying them")
-	exists := flag.Bool("exists", false, "Check whether .repver exists and contains the specified command")
-	noColor := flag.Bool("no-color", false, "Disable colored output")
-
-	flag.Parse()
-
-	Debug = *debug
-	DryRun = *dryRun
-	NoColor = *noColor
-	UserCommand = *command
-	Exists = *exists
+// Subcommand is the optional first positional argument selecting a mode (e.g. help)
+var Subcommand string
+
+// Args holds the positional arguments that follow the flags
+var Args []string
+
+// SubcommandUsage describes a subcommand and the arguments it takes
+type SubcommandUsage struct {
+	Name  string
+	Usage string
+}
+
+// Subcommands lists the subcommands in the order shown in the help. A first
+// argument split off by SplitSubcommand must name one of them.
+var Subcommands = []SubcommandUsage{
+	{"sync", "--command=<command_name> [OPTIONS]"},
+	{"plan", "--command=<command_name> [--param-<n>=<value> ...] --out=<plan file>"},
+	{"apply", "[OPTIONS] <plan file>"},
+	{"check", "[--command=<command_name>]"},
+	{"get", "--command=<command_name> [--format=text|json|env]"},
+	{"help", "[<command_name>]"},
+}
+
+// IsSubcommand checks if name is one of the Subcommands
+func IsSubcommand(name string) bool {
+	for _, subcommand := range Subcommands {
+		if subcommand.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// SplitSubcommand separates a leading subcommand from the remaining arguments.
+// A subcommand is a first argument that does not start with "-"; if there is
+// none, the subcommand is empty and all arguments are returned unchanged.
+// Unknown subcommands are split off too so they can be reported.
+func SplitSubcommand(args []string) (string, []string) {
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		return args[0], args[1:]
+	}
+	return "", args
+}
+
+// Flags holds the values of the static command-line flags once parsed
+type Flags struct {
+	Debug          *bool
+	Command        *string
+	DryRun         *bool
+	Exists         *bool
+	NoColor        *bool
+	NoInput        *bool
+	Help           *bool
+	Check          *bool
+	Format         *string
+	Output         *string
+	Diff           *bool
+	DiffContext    *int
+	PatchOut       *string
+	PlanOut        *string
+	AllowDowngrade *bool
+	Version        *bool
+}
+
+// RegisterFlags defines the static command-line flags on fs. The pre-parse in
+// main and ParseParams share it so both accept the same flags.
+func RegisterFlags(fs *flag.FlagSet) *Flags {
+	return &Flags{
+		Debug:          fs.Bool("debug", false, "Enable debug mode"),
+		Command:        fs.String("command", "", "Command to execute"),
+		DryRun:         fs.Bool("dry-run", false, "Dry run mode - shows changes without applying them"),
+		Exists:         fs.Bool("exists", false, "Check whether .repver exists and contains the specified command"),
+		NoColor:        fs.Bool("no-color", false, "Disable colored output"),
+		NoInput:        fs.Bool("no-input", false, "Never prompt for missing parameters"),
+		Help:           fs.Bool("help", false, "Show help, or the help for a single command when combined with --command"),
+		Check:          fs.Bool("check", false, "Check that the values in every target agree without changing anything"),
+		Format:         fs.String("format", "text", "Output format for the get subcommand (text, json, env)"),
+		Output:         fs.String("output", "text", "Output format for a run (text, json)"),
+		Diff:           fs.Bool("diff", false, "Show file changes as unified diffs"),
+		DiffContext:    fs.Int("diff-context", 3, "Number of context lines in unified diffs"),
+		PatchOut:       fs.String("patch-out", "", "Write the changes to a patch file instead of modifying files"),
+		PlanOut:        fs.String("out", "", "File the plan subcommand writes the plan to"),
+		AllowDowngrade: fs.Bool("allow-downgrade", false, "Allow changes that lower a value protected by a monotonic policy"),
+		Version:        fs.Bool("version", false, "Print version"),
+	}
+}
+
+// ParseParams parses the command-line flags and sets the global variables.
+// The param-* flags must already be defined on flag.CommandLine.
+func ParseParams() *Flags {
+	flags := RegisterFlags(flag.CommandLine)
+
+	subcommand, args := SplitSubcommand(os.Args[1:])
+	// Errors are handled by the flag package which exits on failure
+	_ = flag.CommandLine.Parse(args)
+
+	Debug = *flags.Debug
+	DryRun = *flags.DryRun
+	NoColor = *flags.NoColor
+	UserCommand = *flags.Command
+	Exists = *flags.Exists
+	AllowDowngrade = *flags.AllowDowngrade
+	NoInput = *flags.NoInput
+	Help = *flags.Help
+	Check = *flags.Check
+	Format = *flags.Format
+	Output = *flags.Output
+	Diff = *flags.Diff
+	DiffContext = *flags.DiffContext
+	PatchOut = *flags.PatchOut
+	PlanOut = *flags.PlanOut
+	Subcommand = subcommand
+	Args = flag.Args()
+	return flags
 }
 
 // Debugln prints debug messages to stderr if Debug mode is enabled
@@ -0,0 +1,70 @@
+package repver
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/UnitVectorY-Labs/repver/internal/color"
+)
+
+// IsInteractive checks if repver can prompt the user for input, which requires
+// stdin to be a terminal and prompting not to be disabled with --no-input
+func IsInteractive() bool {
+	if NoInput {
+		return false
+	}
+	info, err := os.Stdin.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+// PromptForParam asks the user for the value of a parameter, showing its description
+// and example when the param is defined. An empty answer selects the default value
+// if one is provided. Answers that do not match the param's pattern are rejected
+// and the user is asked again until a valid value is entered or input ends.
+func PromptForParam(in *bufio.Reader, out io.Writer, name string, param *RepverParam, defaultValue string) (string, error) {
+	fmt.Fprintf(out, "\n%s\n", color.Boldf("Parameter '%s'", name))
+	if param != nil && param.Description != "" {
+		fmt.Fprintf(out, "  %s\n", param.Description)
+	}
+	if param != nil && param.Example != "" {
+		fmt.Fprintf(out, "  Example: %s\n", param.Example)
+	}
+
+	for {
+		if defaultValue != "" {
+			fmt.Fprintf(out, "%s [%s]: ", name, defaultValue)
+		} else {
+			fmt.Fprintf(out, "%s: ", name)
+		}
+
+		answer, err := in.ReadString('\n')
+		answer = strings.TrimSpace(answer)
+		if err != nil && (err != io.EOF || answer == "") {
+			fmt.Fprintln(out)
+			return "", fmt.Errorf("no value entered for parameter '%s'", name)
+		}
+
+		if answer == "" {
+			if defaultValue == "" {
+				fmt.Fprintln(out, color.Red("A value is required"))
+				continue
+			}
+			answer = defaultValue
+		}
+
+		if param != nil {
+			if err := param.ValidateValue(answer); err != nil {
+				fmt.Fprintln(out, color.Redf("Invalid value: %v", err))
+				continue
+			}
+		}
+
+		return answer, nil
+	}
+}
@@ -0,0 +1,90 @@
+package repver
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/UnitVectorY-Labs/repver/internal/color"
+)
+
+func TestPromptForParam(t *testing.T) {
+	color.Enabled = false
+	defer func() { color.Enabled = true }()
+
+	param := &RepverParam{
+		Name:        "version",
+		Pattern:     `^\d+\.\d+\.\d+$`,
+		Description: "The Go version to use",
+		Example:     "1.23.0",
+	}
+
+	tests := []struct {
+		name         string
+		input        string
+		param        *RepverParam
+		defaultValue string
+		expected     string
+		shouldError  bool
+	}{
+		{"valid answer", "1.23.0\n", param, "", "1.23.0", false},
+		{"default on empty answer", "\n", param, "1.22.3", "1.22.3", false},
+		{"re-prompt after invalid answer", "latest\n1.23.1\n", param, "", "1.23.1", false},
+		{"re-prompt after empty answer", "\n1.23.2\n", param, "", "1.23.2", false},
+		{"answer without newline", "1.23.3", param, "", "1.23.3", false},
+		{"no param definition", "anything\n", nil, "", "anything", false},
+		{"input ends", "", param, "", "", true},
+		{"input ends after invalid answer", "latest\n", param, "", "", true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var out strings.Builder
+			value, err := PromptForParam(bufio.NewReader(strings.NewReader(tc.input)), &out, "version", tc.param, tc.defaultValue)
+			if tc.shouldError {
+				if err == nil {
+					t.Errorf("expected error but got value %q", value)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if value != tc.expected {
+				t.Errorf("expected %q, got %q", tc.expected, value)
+			}
+			if tc.param != nil && !strings.Contains(out.String(), "Example: 1.23.0") {
+				t.Errorf("expected prompt to show the example, got:\n%s", out.String())
+			}
+		})
+	}
+}
+
+func TestGetCurrentParamValue(t *testing.T) {
+	tmpDir := t.TempDir()
+	goMod := filepath.Join(tmpDir, "go.mod")
+	nvmrc := filepath.Join(tmpDir, ".nvmrc")
+	if err := os.WriteFile(goMod, []byte("module example\n\ngo 1.22 // GOVERSION\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(nvmrc, []byte("20.11.1\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	command := RepverCommand{
+		Name: "test",
+		Targets: []RepverTarget{
+			{Path: goMod, Pattern: `^go (?P<version>.*) // GOVERSION$`, Transform: "{{major}}.{{minor}}"},
+			{Path: nvmrc, Pattern: `^(?P<v>\d+\.\d+\.\d+)$`, Bind: map[string]string{"v": "node"}},
+		},
+	}
+
+	if got := command.GetCurrentParamValue("node"); got != "20.11.1" {
+		t.Errorf("expected current node value 20.11.1, got %q", got)
+	}
+	if got := command.GetCurrentParamValue("version"); got != "" {
+		t.Errorf("expected transformed target to be skipped, got %q", got)
+	}
+}
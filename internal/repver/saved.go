@@ -0,0 +1,230 @@
+package repver
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// SavedPlanVersion is the version of the saved plan format written by repver plan
+const SavedPlanVersion = 1
+
+// SavedPlan is a plan serialized by the plan subcommand so it can be reviewed
+// and later executed by the apply subcommand
+type SavedPlan struct {
+	Version int               `json:"version"`
+	Command string            `json:"command"`
+	Params  map[string]string `json:"params"`
+	Groups  map[string]string `json:"groups"`
+	Files   []SavedFile       `json:"files"`
+	Git     SavedGit          `json:"git"`
+	Hooks   SavedHooks        `json:"hooks"`
+}
+
+// SavedHooks records the rendered hook and verify commands run when the plan
+// is applied. The pre_plan hooks are not run when the plan is created, as the
+// plan cannot carry the files they change; they run before it is applied.
+type SavedHooks struct {
+	PrePlan   []string `json:"pre_plan,omitempty"`
+	PostApply []string `json:"post_apply,omitempty"`
+	PreCommit []string `json:"pre_commit,omitempty"`
+	Verify    []string `json:"verify,omitempty"`
+	Timeout   string   `json:"timeout,omitempty"`
+}
+
+// SavedFile records the planned changes to one target along with the hash of
+// the file as it was when the plan was created
+type SavedFile struct {
+	Path            string              `json:"path"`
+	SHA256          string              `json:"sha256"`
+	Modified        bool                `json:"modified"`
+	ModifiedContent string              `json:"modified_content,omitempty"`
+	Changes         []FileChange        `json:"changes"`
+	OldValues       map[string][]string `json:"old_values"`
+}
+
+// SavedGit records the git operations to perform with the branch name, commit
+// message and tag already rendered; the message includes the commit body and trailers
+type SavedGit struct {
+	CreateBranch           bool              `json:"create_branch"`
+	Branch                 string            `json:"branch,omitempty"`
+	BaseBranch             string            `json:"base_branch,omitempty"`
+	Fetch                  bool              `json:"fetch,omitempty"`
+	OnExistingBranch       string            `json:"on_existing_branch,omitempty"`
+	Commit                 bool              `json:"commit"`
+	CommitMessage          string            `json:"commit_message,omitempty"`
+	Sign                   string            `json:"sign,omitempty"`
+	Author                 string            `json:"author,omitempty"`
+	Signoff                bool              `json:"signoff,omitempty"`
+	Tag                    bool              `json:"tag,omitempty"`
+	TagName                string            `json:"tag_name,omitempty"`
+	TagMessage             string            `json:"tag_message,omitempty"`
+	TagSign                string            `json:"tag_sign,omitempty"`
+	PushTags               bool              `json:"push_tags,omitempty"`
+	Push                   bool              `json:"push"`
+	Remote                 string            `json:"remote,omitempty"`
+	PullRequest            string            `json:"pull_request,omitempty"`
+	ReturnToOriginalBranch bool              `json:"return_to_original_branch"`
+	DeleteBranch           bool              `json:"delete_branch"`
+	DirtyTree              string            `json:"dirty_tree,omitempty"`
+	Timeout                string            `json:"timeout,omitempty"`
+	Timeouts               map[string]string `json:"timeouts,omitempty"`
+}
+
+// NewSavedPlan builds a saved plan from the execution plans of a command and
+// the rendered branch name, commit message, tag name and tag message
+func NewSavedPlan(c *RepverCommand, params map[string]string, groups map[string]string, plans []*ExecutionPlan, branchName string, commitMessage string, tagName string, tagMessage string) *SavedPlan {
+	saved := &SavedPlan{
+		Version: SavedPlanVersion,
+		Command: c.Name,
+		Params:  params,
+		Groups:  groups,
+		Files:   []SavedFile{},
+		Git: SavedGit{
+			CreateBranch:           c.GitOptions.CreateBranch,
+			Branch:                 branchName,
+			BaseBranch:             c.GitOptions.BaseBranch,
+			Fetch:                  c.GitOptions.Fetch,
+			OnExistingBranch:       c.GitOptions.OnExistingBranch,
+			Commit:                 c.GitOptions.Commit,
+			CommitMessage:          commitMessage,
+			Sign:                   c.GitOptions.Sign,
+			Author:                 c.GitOptions.Author,
+			Signoff:                c.GitOptions.Signoff,
+			Tag:                    c.GitOptions.Tag,
+			TagName:                tagName,
+			TagMessage:             tagMessage,
+			TagSign:                c.GitOptions.TagSign,
+			PushTags:               c.GitOptions.PushTags,
+			Push:                   c.GitOptions.Push,
+			Remote:                 c.GitOptions.Remote,
+			PullRequest:            c.GitOptions.PullRequest,
+			ReturnToOriginalBranch: c.GitOptions.ReturnToOriginalBranch,
+			DeleteBranch:           c.GitOptions.DeleteBranch,
+			DirtyTree:              c.GitOptions.DirtyTree,
+			Timeout:                c.GitOptions.Timeout,
+			Timeouts:               c.GitOptions.Timeouts,
+		},
+	}
+
+	for _, plan := range plans {
+		file := SavedFile{
+			Path:      plan.Path,
+			SHA256:    hashContent(plan.OriginalContent),
+			Modified:  plan.Modified,
+			Changes:   plan.Changes,
+			OldValues: plan.OldValues,
+		}
+		if plan.Modified {
+			file.ModifiedContent = plan.ModifiedContent
+		}
+		saved.Files = append(saved.Files, file)
+	}
+
+	return saved
+}
+
+// Write serializes the saved plan as JSON to the given path
+func (s *SavedPlan) Write(path string) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0644)
+}
+
+// LoadSavedPlan reads a plan written by the plan subcommand
+func LoadSavedPlan(path string) (*SavedPlan, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	saved := &SavedPlan{}
+	if err := json.Unmarshal(data, saved); err != nil {
+		return nil, fmt.Errorf("invalid plan file: %w", err)
+	}
+	if saved.Version != SavedPlanVersion {
+		return nil, fmt.Errorf("unsupported plan version %d (expected %d)", saved.Version, SavedPlanVersion)
+	}
+
+	return saved, nil
+}
+
+// ExecutionPlans verifies that every file still has the content it had when
+// the plan was created and returns the execution plans to apply. It returns
+// an error listing every file that changed since planning.
+func (s *SavedPlan) ExecutionPlans() ([]*ExecutionPlan, error) {
+	var plans []*ExecutionPlan
+	var changed []string
+
+	for _, file := range s.Files {
+		content, err := os.ReadFile(file.Path)
+		if err != nil {
+			changed = append(changed, fmt.Sprintf("%s: %v", file.Path, err))
+			continue
+		}
+		if hashContent(string(content)) != file.SHA256 {
+			changed = append(changed, fmt.Sprintf("%s: content changed since the plan was created", file.Path))
+			continue
+		}
+
+		modifiedContent := string(content)
+		if file.Modified {
+			modifiedContent = file.ModifiedContent
+		}
+		plans = append(plans, &ExecutionPlan{
+			Path:            file.Path,
+			Modified:        file.Modified,
+			OriginalContent: string(content),
+			ModifiedContent: modifiedContent,
+			Changes:         file.Changes,
+			OldValues:       file.OldValues,
+		})
+	}
+
+	if len(changed) > 0 {
+		return nil, fmt.Errorf("%d file(s) changed since the plan was created:\n  %s", len(changed), strings.Join(changed, "\n  "))
+	}
+
+	return plans, nil
+}
+
+// GitOptions returns the git options to execute, using the rendered branch
+// name, commit message and tag in place of their templates
+func (g SavedGit) GitOptions() RepverGit {
+	return RepverGit{
+		CreateBranch:           g.CreateBranch,
+		DeleteBranch:           g.DeleteBranch,
+		BranchName:             g.Branch,
+		BaseBranch:             g.BaseBranch,
+		Fetch:                  g.Fetch,
+		OnExistingBranch:       g.OnExistingBranch,
+		Commit:                 g.Commit,
+		CommitMessage:          g.CommitMessage,
+		Sign:                   g.Sign,
+		Author:                 g.Author,
+		Signoff:                g.Signoff,
+		Tag:                    g.Tag,
+		TagName:                g.TagName,
+		TagMessage:             g.TagMessage,
+		TagSign:                g.TagSign,
+		PushTags:               g.PushTags,
+		Push:                   g.Push,
+		Remote:                 g.Remote,
+		PullRequest:            g.PullRequest,
+		ReturnToOriginalBranch: g.ReturnToOriginalBranch,
+		DirtyTree:              g.DirtyTree,
+		Timeout:                g.Timeout,
+		Timeouts:               g.Timeouts,
+	}
+}
+
+// hashContent returns the hex encoded SHA-256 hash of the content
+func hashContent(content string) string {
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,109 @@
+package repver
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestSavedPlanRoundTrip(t *testing.T) {
+	tmpDir := t.TempDir()
+	targetPath := filepath.Join(tmpDir, "version.txt")
+	if err := os.WriteFile(targetPath, []byte("version: 1.2.3\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	command := RepverCommand{
+		Name:       "test",
+		Targets:    []RepverTarget{{Path: targetPath, Pattern: `^version: (?P<version>.*)$`}},
+		GitOptions: RepverGit{Commit: true, CommitMessage: "Update to {{version}}"},
+	}
+	plan, err := command.Targets[0].Plan(map[string]string{"version": "1.3.0"}, nil)
+	if err != nil {
+		t.Fatalf("Plan returned error: %v", err)
+	}
+
+	planPath := filepath.Join(tmpDir, "plan.json")
+	saved := NewSavedPlan(&command, map[string]string{"version": "1.3.0"}, map[string]string{}, []*ExecutionPlan{plan}, "", "Update to 1.3.0", "", "")
+	if err := saved.Write(planPath); err != nil {
+		t.Fatalf("Write returned error: %v", err)
+	}
+
+	loaded, err := LoadSavedPlan(planPath)
+	if err != nil {
+		t.Fatalf("LoadSavedPlan returned error: %v", err)
+	}
+	if options := loaded.Git.GitOptions(); !options.Commit || options.CommitMessage != "Update to 1.3.0" {
+		t.Errorf("unexpected git options: %+v", options)
+	}
+
+	plans, err := loaded.ExecutionPlans()
+	if err != nil {
+		t.Fatalf("ExecutionPlans returned error: %v", err)
+	}
+	if len(plans) != 1 || !plans[0].Modified || plans[0].ModifiedContent != "version: 1.3.0\n" {
+		t.Errorf("unexpected execution plans: %+v", plans)
+	}
+
+	// A target that changed after planning makes the plan stale
+	if err := os.WriteFile(targetPath, []byte("version: 1.2.4\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := loaded.ExecutionPlans(); err == nil {
+		t.Error("expected an error for a target changed since planning")
+	}
+}
+
+// TestSavedGitKeepsEveryOption fails when a RepverGit option is added without
+// being saved in SavedGit, which would silently drop it on apply
+func TestSavedGitKeepsEveryOption(t *testing.T) {
+	// Rendered into the saved branch, commit message and tag instead of being kept
+	rendered := map[string]string{
+		"BranchName":    "rendered-branch",
+		"CommitMessage": "rendered-message",
+		"CommitBody":    "",
+		"Trailers":      "",
+		"TagName":       "rendered-tag",
+		"TagMessage":    "rendered-tag-message",
+	}
+
+	var original RepverGit
+	value := reflect.ValueOf(&original).Elem()
+	for i := 0; i < value.NumField(); i++ {
+		field := value.Field(i)
+		name := value.Type().Field(i).Name
+		switch field.Kind() {
+		case reflect.Bool:
+			field.SetBool(true)
+		case reflect.String:
+			field.SetString("value-" + name)
+		case reflect.Map:
+			field.Set(reflect.ValueOf(map[string]string{"key": "value-" + name}))
+		default:
+			t.Fatalf("field %s has a type this test does not fill: %s", name, field.Type())
+		}
+	}
+
+	command := &RepverCommand{Name: "test", GitOptions: original}
+	saved := NewSavedPlan(command, nil, nil, nil, rendered["BranchName"], rendered["CommitMessage"], rendered["TagName"], rendered["TagMessage"])
+	restored := reflect.ValueOf(saved.Git.GitOptions())
+
+	for i := 0; i < value.NumField(); i++ {
+		name := value.Type().Field(i).Name
+		got := restored.Field(i).Interface()
+		if want, ok := rendered[name]; ok {
+			if want == "" {
+				if !restored.Field(i).IsZero() {
+					t.Errorf("expected %s to be folded into the commit message, got %v", name, got)
+				}
+			} else if got != want {
+				t.Errorf("expected %s to be the rendered %q, got %v", name, want, got)
+			}
+			continue
+		}
+		if want := value.Field(i).Interface(); !reflect.DeepEqual(got, want) {
+			t.Errorf("RepverGit.%s is not kept by SavedGit: saved %v, restored %v", name, want, got)
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package repver
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"text/template"
+	"text/template/parse"
+)
+
+// Pre-compiled regex pattern for values that need no quoting in a POSIX shell
+var shellSafeRegex = regexp.MustCompile(`^[A-Za-z0-9_@%+=:,./-]+$`)
+
+// shellQuoteFunc is the name of the function appended to every action of a
+// shell command template
+const shellQuoteFunc = "repverShellQuote"
+
+// ShellQuote quotes a value so a POSIX shell reads it back as a single word.
+// Values made only of characters with no meaning to the shell, such as most
+// version numbers, are returned unchanged; anything else is wrapped in single
+// quotes, closing and escaping the quotes around any embedded single quote.
+func ShellQuote(value string) string {
+	if shellSafeRegex.MatchString(value) {
+		return value
+	}
+	return "'" + strings.ReplaceAll(value, "'", `'\''`) + "'"
+}
+
+// RenderShellTemplate renders the template text of a shell command. Template
+// functions see the values as they are, and the output of every action is
+// shell-quoted afterwards, so a value holding ";", "$()" or quotes stays a
+// single word whatever functions were applied to it.
+func RenderShellTemplate(text string, values map[string]string) (string, error) {
+	if text == "" {
+		return "", nil
+	}
+
+	tmpl, err := parseTemplate(text, template.FuncMap{
+		shellQuoteFunc: func(value any) string { return ShellQuote(fmt.Sprint(value)) },
+	})
+	if err != nil {
+		return "", err
+	}
+	if tmpl.Tree != nil {
+		quoteActions(tmpl.Tree, tmpl.Tree.Root)
+	}
+
+	return executeTemplate(tmpl, values)
+}
+
+// quoteActions appends the shell quote function to the pipeline of every
+// action that prints a value, including those inside if, range and with
+// blocks. Actions that only declare or assign variables print nothing and are
+// left alone.
+func quoteActions(tree *parse.Tree, node parse.Node) {
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return
+		}
+		for _, child := range n.Nodes {
+			quoteActions(tree, child)
+		}
+	case *parse.ActionNode:
+		if len(n.Pipe.Decl) > 0 {
+			return
+		}
+		quote := &parse.CommandNode{
+			NodeType: parse.NodeCommand,
+			Pos:      n.Pos,
+			Args:     []parse.Node{parse.NewIdentifier(shellQuoteFunc).SetTree(tree).SetPos(n.Pos)},
+		}
+		n.Pipe.Cmds = append(n.Pipe.Cmds, quote)
+	case *parse.IfNode:
+		quoteActions(tree, n.List)
+		quoteActions(tree, n.ElseList)
+	case *parse.RangeNode:
+		quoteActions(tree, n.List)
+		quoteActions(tree, n.ElseList)
+	case *parse.WithNode:
+		quoteActions(tree, n.List)
+		quoteActions(tree, n.ElseList)
+	}
+}
@@ -0,0 +1,88 @@
+package repver
+
+import (
+	"os/exec"
+	"testing"
+)
+
+func TestShellQuote(t *testing.T) {
+	tests := []struct {
+		value    string
+		expected string
+	}{
+		{"1.22.3", "1.22.3"},
+		{"v1.2.3-rc.1+build", "v1.2.3-rc.1+build"},
+		{"", "''"},
+		{"go 1.22", "'go 1.22'"},
+		{"a#b", "'a#b'"},
+		{"$(whoami)", "'$(whoami)'"},
+		{"it's", `'it'\''s'`},
+		{"line1\nline2", "'line1\nline2'"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.value, func(t *testing.T) {
+			quoted := ShellQuote(tc.value)
+			if quoted != tc.expected {
+				t.Errorf("ShellQuote(%q) = %q, want %q", tc.value, quoted, tc.expected)
+			}
+
+			// The shell must read the quoted value back unchanged
+			output, err := exec.Command("sh", "-c", "printf %s "+quoted).Output()
+			if err != nil {
+				t.Fatalf("sh failed: %v", err)
+			}
+			if string(output) != tc.value {
+				t.Errorf("sh read %q back as %q", quoted, output)
+			}
+		})
+	}
+}
+
+func TestRenderCommandsQuotesValues(t *testing.T) {
+	value := `1.0; echo injected $(echo sub) "it's"`
+	hooks := RepverHooks{PostApply: []string{"printf %s {{version}}"}}
+	commands, err := hooks.RenderCommands("post_apply", map[string]string{"version": value})
+	if err != nil {
+		t.Fatalf("RenderCommands returned error: %v", err)
+	}
+
+	output, err := exec.Command("sh", "-c", commands[0]).Output()
+	if err != nil {
+		t.Fatalf("sh failed: %v", err)
+	}
+	if string(output) != value {
+		t.Errorf("expected the value as a single argument, got %q from %q", output, commands[0])
+	}
+}
+
+func TestRenderShellTemplate(t *testing.T) {
+	tests := []struct {
+		name     string
+		template string
+		value    string
+		expected string
+	}{
+		{"plain value", "echo {{version}}", "1.3.0", "echo 1.3.0"},
+		{"unsafe value", "echo {{version}}", "v1.3.0 beta", "echo 'v1.3.0 beta'"},
+		{"function sees the raw value", `echo {{ trimPrefix "v" .version }}`, "v1.3.0 beta", "echo '1.3.0 beta'"},
+		{"pipeline", `echo {{ .version | upper }}`, "v1.3.0 beta", "echo 'V1.3.0 BETA'"},
+		{"function removing quotes", `echo {{ regexReplace "'" "" .version }}`, "1.0'; touch pwned; '", "echo '1.0; touch pwned; '"},
+		{"trimSuffix removing a quote", `echo {{ trimSuffix "'" .version }}`, "x'$(whoami)'", `echo 'x'\''$(whoami)'`},
+		{"if block", `echo {{ if hasPrefix "v" .version }}{{ .version }}{{ else }}none{{ end }}`, "v1 2", "echo 'v1 2'"},
+		{"range block", `echo {{ range split "," .version }}{{ . }} {{ end }}`, "a b,c", "echo 'a b' c "},
+		{"variable declaration", `{{ $v := .version }}echo {{ $v }}`, "a;b", "echo 'a;b'"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			rendered, err := RenderShellTemplate(tc.template, map[string]string{"version": tc.value})
+			if err != nil {
+				t.Fatalf("RenderShellTemplate returned error: %v", err)
+			}
+			if rendered != tc.expected {
+				t.Errorf("RenderShellTemplate(%q) = %q, want %q", tc.template, rendered, tc.expected)
+			}
+		})
+	}
+}
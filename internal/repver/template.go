@@ -0,0 +1,363 @@
+package repver
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"text/template"
+	"text/template/parse"
+	"time"
+)
+
+// Pre-compiled regex pattern for the bare {{name}} placeholder syntax
+var barePlaceholderRegex = regexp.MustCompile(`\{\{(-?\s*)([A-Za-z_][A-Za-z0-9_]*(?:\.[A-Za-z_][A-Za-z0-9_]*)*)(\s*-?)\}\}`)
+
+// templateKeywords are identifiers that have a meaning of their own inside a
+// template action and are never treated as bare placeholders
+var templateKeywords = map[string]bool{
+	"if": true, "else": true, "end": true, "range": true, "with": true,
+	"define": true, "template": true, "block": true, "break": true,
+	"continue": true, "nil": true, "true": true, "false": true,
+}
+
+// BuiltinVariables lists the built-in variables available in every template
+var BuiltinVariables = []string{
+	"date",
+	"timestamp",
+	"command",
+	"git.short_sha",
+	"git.branch",
+	"git.user",
+	"repo.name",
+	"repo.owner",
+}
+
+// templateFuncs is the function library available to every template
+var templateFuncs = template.FuncMap{
+	// String operations
+	"upper":      strings.ToUpper,
+	"lower":      strings.ToLower,
+	"title":      titleCase,
+	"trim":       strings.TrimSpace,
+	"trimPrefix": func(prefix string, s string) string { return strings.TrimPrefix(s, prefix) },
+	"trimSuffix": func(suffix string, s string) string { return strings.TrimSuffix(s, suffix) },
+	"replace":    func(old string, new string, s string) string { return strings.ReplaceAll(s, old, new) },
+	"contains":   func(substr string, s string) bool { return strings.Contains(s, substr) },
+	"hasPrefix":  func(prefix string, s string) bool { return strings.HasPrefix(s, prefix) },
+	"hasSuffix":  func(suffix string, s string) bool { return strings.HasSuffix(s, suffix) },
+	"split":      func(sep string, s string) []string { return strings.Split(s, sep) },
+	"join":       func(sep string, elems []string) string { return strings.Join(elems, sep) },
+	"default":    defaultValue,
+
+	// Regular expressions
+	"regexReplace": regexReplace,
+	"regexMatch":   regexMatch,
+
+	// Semantic version helpers
+	"semverMajor":      func(v string) (string, error) { return semverComponent(v, 1) },
+	"semverMinor":      func(v string) (string, error) { return semverComponent(v, 2) },
+	"semverPatch":      func(v string) (string, error) { return semverComponent(v, 3) },
+	"semverPrerelease": func(v string) (string, error) { return semverComponent(v, 4) },
+	"semverIncMajor":   func(v string) (string, error) { return semverIncrement(v, 1) },
+	"semverIncMinor":   func(v string) (string, error) { return semverIncrement(v, 2) },
+	"semverIncPatch":   func(v string) (string, error) { return semverIncrement(v, 3) },
+
+	// Date formatting
+	"now":        time.Now,
+	"formatDate": formatDate,
+}
+
+// BuiltinValues returns the built-in template values that do not depend on
+// the Git repository: the current date and timestamp in UTC and the command name
+func BuiltinValues(command string, now time.Time) map[string]string {
+	now = now.UTC()
+	return map[string]string{
+		"date":      now.Format("2006-01-02"),
+		"timestamp": now.Format("20060102150405"),
+		"command":   command,
+	}
+}
+
+// isBuiltinVariable checks if name is one of the built-in variables
+func isBuiltinVariable(name string) bool {
+	return slices.Contains(BuiltinVariables, name)
+}
+
+// validateTemplateVariables checks that every variable referenced by the
+// template can be resolved from the available variables
+func validateTemplateVariables(text string, available map[string]bool) error {
+	variables, err := TemplateVariables(text)
+	if err != nil {
+		return err
+	}
+
+	for _, name := range variables {
+		if available[name] {
+			continue
+		}
+		root, _, _ := strings.Cut(name, ".")
+		if (root == "git" || root == "repo") && !isBuiltinVariable(name) {
+			return fmt.Errorf("unknown built-in variable '{{%s}}', available built-in variables: %v", name, BuiltinVariables)
+		}
+		return fmt.Errorf("unknown variable '{{%s}}', available variables: %v", name, getMapKeys(available))
+	}
+
+	return nil
+}
+
+// normalizeTemplate rewrites the bare {{name}} placeholder syntax into the
+// {{.name}} field syntax used by text/template. Function names and template
+// keywords are left untouched so {{now}} or {{end}} keep their meaning.
+func normalizeTemplate(text string) string {
+	return barePlaceholderRegex.ReplaceAllStringFunc(text, func(match string) string {
+		parts := barePlaceholderRegex.FindStringSubmatch(match)
+		name := parts[2]
+		root := strings.SplitN(name, ".", 2)[0]
+		if templateKeywords[root] {
+			return match
+		}
+		if _, ok := templateFuncs[root]; ok && !strings.Contains(name, ".") {
+			return match
+		}
+		return "{{" + parts[1] + "." + name + parts[3] + "}}"
+	})
+}
+
+// parseTemplate parses the template text with the repver function library
+// and any extra functions used internally by the caller.
+func parseTemplate(text string, extra ...template.FuncMap) (*template.Template, error) {
+	tmpl := template.New("repver").
+		Funcs(templateFuncs).
+		Option("missingkey=error")
+	for _, funcs := range extra {
+		tmpl = tmpl.Funcs(funcs)
+	}
+	tmpl, err := tmpl.Parse(normalizeTemplate(text))
+	if err != nil {
+		return nil, fmt.Errorf("invalid template: %w", err)
+	}
+	return tmpl, nil
+}
+
+// RenderTemplate renders the template text using the provided values.
+// Keys containing dots (e.g. "git.branch") are exposed as nested fields so
+// they can be referenced as {{git.branch}} or {{.git.branch}}.
+func RenderTemplate(text string, values map[string]string) (string, error) {
+	if text == "" {
+		return "", nil
+	}
+
+	tmpl, err := parseTemplate(text)
+	if err != nil {
+		return "", err
+	}
+
+	return executeTemplate(tmpl, values)
+}
+
+// executeTemplate renders a parsed template using the provided values.
+func executeTemplate(tmpl *template.Template, values map[string]string) (string, error) {
+	var result strings.Builder
+	if err := tmpl.Execute(&result, buildTemplateData(values)); err != nil {
+		return "", fmt.Errorf("failed to render template: %w", err)
+	}
+
+	return result.String(), nil
+}
+
+// TemplateVariables returns the unique variable names referenced by the
+// template text in the order they first appear. Nested variables are
+// returned in dotted form (e.g. "git.branch").
+func TemplateVariables(text string) ([]string, error) {
+	tmpl, err := parseTemplate(text)
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	seen := make(map[string]bool)
+	add := func(name string) {
+		if !seen[name] {
+			names = append(names, name)
+			seen[name] = true
+		}
+	}
+
+	if tmpl.Tree != nil {
+		collectTemplateFields(tmpl.Tree.Root, add)
+	}
+
+	return names, nil
+}
+
+// collectTemplateFields walks a parsed template and reports every field
+// reference relative to the top-level data. The bodies of range and with
+// blocks are skipped because the meaning of "." changes inside them.
+func collectTemplateFields(node parse.Node, add func(string)) {
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return
+		}
+		for _, child := range n.Nodes {
+			collectTemplateFields(child, add)
+		}
+	case *parse.ActionNode:
+		collectTemplateFields(n.Pipe, add)
+	case *parse.IfNode:
+		collectTemplateFields(n.Pipe, add)
+		collectTemplateFields(n.List, add)
+		collectTemplateFields(n.ElseList, add)
+	case *parse.RangeNode:
+		collectTemplateFields(n.Pipe, add)
+		collectTemplateFields(n.ElseList, add)
+	case *parse.WithNode:
+		collectTemplateFields(n.Pipe, add)
+		collectTemplateFields(n.ElseList, add)
+	case *parse.TemplateNode:
+		collectTemplateFields(n.Pipe, add)
+	case *parse.PipeNode:
+		if n == nil {
+			return
+		}
+		for _, cmd := range n.Cmds {
+			collectTemplateFields(cmd, add)
+		}
+	case *parse.CommandNode:
+		for _, arg := range n.Args {
+			collectTemplateFields(arg, add)
+		}
+	case *parse.FieldNode:
+		add(strings.Join(n.Ident, "."))
+	case *parse.ChainNode:
+		collectTemplateFields(n.Node, add)
+	}
+}
+
+// buildTemplateData converts a flat map of values into the nested structure
+// used as template data, splitting dotted keys into nested maps.
+func buildTemplateData(values map[string]string) map[string]any {
+	data := make(map[string]any)
+	for key, val := range values {
+		parts := strings.Split(key, ".")
+		current := data
+		for _, part := range parts[:len(parts)-1] {
+			next, ok := current[part].(map[string]any)
+			if !ok {
+				next = make(map[string]any)
+				current[part] = next
+			}
+			current = next
+		}
+		leaf := parts[len(parts)-1]
+		if _, isMap := current[leaf].(map[string]any); !isMap {
+			current[leaf] = val
+		}
+	}
+	return data
+}
+
+// titleCase upper-cases the first letter of each space separated word.
+func titleCase(s string) string {
+	words := strings.Split(s, " ")
+	for i, word := range words {
+		if word != "" {
+			words[i] = strings.ToUpper(word[:1]) + word[1:]
+		}
+	}
+	return strings.Join(words, " ")
+}
+
+// defaultValue returns def when value is empty or missing.
+func defaultValue(def any, value any) any {
+	if value == nil {
+		return def
+	}
+	if s, ok := value.(string); ok && s == "" {
+		return def
+	}
+	return value
+}
+
+// regexReplace replaces all matches of pattern in s with repl, which may
+// reference capture groups using $1 or ${name} syntax.
+func regexReplace(pattern string, repl string, s string) (string, error) {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return "", fmt.Errorf("regexReplace: %w", err)
+	}
+	return re.ReplaceAllString(s, repl), nil
+}
+
+// regexMatch checks if s matches pattern.
+func regexMatch(pattern string, s string) (bool, error) {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return false, fmt.Errorf("regexMatch: %w", err)
+	}
+	return re.MatchString(s), nil
+}
+
+// semverComponent returns one component of a semantic version, where index
+// 1-3 select major, minor and patch and 4 selects the prerelease. Missing
+// minor and patch components are returned as "0".
+func semverComponent(v string, index int) (string, error) {
+	matches := semverValueRegex.FindStringSubmatch(v)
+	if matches == nil {
+		return "", fmt.Errorf("value '%s' is not a semantic version", v)
+	}
+	if index <= 3 && matches[index] == "" {
+		return "0", nil
+	}
+	return matches[index], nil
+}
+
+// semverIncrement increments the major (1), minor (2) or patch (3) component
+// of a semantic version, resetting the lower components and dropping any
+// prerelease or build metadata.
+func semverIncrement(v string, index int) (string, error) {
+	matches := semverValueRegex.FindStringSubmatch(v)
+	if matches == nil {
+		return "", fmt.Errorf("value '%s' is not a semantic version", v)
+	}
+
+	parts := make([]int, 3)
+	for i := range parts {
+		if matches[i+1] != "" {
+			n, err := strconv.Atoi(matches[i+1])
+			if err != nil {
+				return "", fmt.Errorf("value '%s' is not a semantic version", v)
+			}
+			parts[i] = n
+		}
+	}
+
+	parts[index-1]++
+	for i := index; i < len(parts); i++ {
+		parts[i] = 0
+	}
+
+	prefix := ""
+	if strings.HasPrefix(v, "v") {
+		prefix = "v"
+	}
+	return fmt.Sprintf("%s%d.%d.%d", prefix, parts[0], parts[1], parts[2]), nil
+}
+
+// formatDate formats a time using a Go reference layout. The value may be a
+// time.Time or an RFC 3339 string.
+func formatDate(layout string, value any) (string, error) {
+	switch v := value.(type) {
+	case time.Time:
+		return v.Format(layout), nil
+	case string:
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return "", fmt.Errorf("formatDate: %w", err)
+		}
+		return t.Format(layout), nil
+	}
+	return "", fmt.Errorf("formatDate: unsupported value %v", value)
+}
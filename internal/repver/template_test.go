@@ -0,0 +1,133 @@
+package repver
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestRenderTemplate(t *testing.T) {
+	values := map[string]string{
+		"version":    "v1.22.3",
+		"major":      "1",
+		"minor":      "22",
+		"name":       "golang",
+		"empty":      "",
+		"git.branch": "main",
+	}
+
+	tests := []struct {
+		name        string
+		template    string
+		expected    string
+		shouldError bool
+	}{
+		{"bare placeholder", "go-{{version}}", "go-v1.22.3", false},
+		{"field syntax", "{{ .major }}.x", "1.x", false},
+		{"trim prefix", `{{ trimPrefix "v" .version }}`, "1.22.3", false},
+		{"upper", "{{ upper .name }}", "GOLANG", false},
+		{"pipeline", `{{ .name | title }}`, "Golang", false},
+		{"default on empty", `{{ default "none" .empty }}`, "none", false},
+		{"conditional", `{{ if eq .major "1" }}legacy{{ else }}modern{{ end }}`, "legacy", false},
+		{"regex replace", `{{ regexReplace "\\." "_" .version }}`, "v1_22_3", false},
+		{"semver helpers", `{{ semverMajor .version }}/{{ semverMinor .version }}/{{ semverPatch .version }}`, "1/22/3", false},
+		{"semver increment", `{{ semverIncMinor .version }}`, "v1.23.0", false},
+		{"nested bare placeholder", "{{git.branch}}", "main", false},
+		{"nested field", "{{ .git.branch }}", "main", false},
+		{"format date", `{{ formatDate "2006" "2024-05-06T07:08:09Z" }}`, "2024", false},
+		{"empty template", "", "", false},
+		{"missing variable", "{{unknown}}", "", true},
+		{"invalid semver", `{{ semverMajor .name }}`, "", true},
+		{"parse error", "{{ if .major }}", "", true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			result, err := RenderTemplate(tc.template, values)
+			if tc.shouldError {
+				if err == nil {
+					t.Errorf("expected error but got none, result %q", result)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if result != tc.expected {
+				t.Errorf("expected %q, got %q", tc.expected, result)
+			}
+		})
+	}
+}
+
+func TestTemplateVariables(t *testing.T) {
+	tests := []struct {
+		name     string
+		template string
+		expected []string
+	}{
+		{"bare placeholders", "{{major}}.{{minor}}", []string{"major", "minor"}},
+		{"duplicates", "{{major}}.{{major}}", []string{"major"}},
+		{"functions and fields", `{{ trimPrefix "v" .version }}`, []string{"version"}},
+		{"function name left alone", "{{now}}", nil},
+		{"nested", "{{git.branch}}-{{ .old.version }}", []string{"git.branch", "old.version"}},
+		{"conditional", `{{ if .major }}{{ .minor }}{{ end }}`, []string{"major", "minor"}},
+		{"static text", "static", nil},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			names, err := TemplateVariables(tc.template)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(names, tc.expected) {
+				t.Errorf("expected %v, got %v", tc.expected, names)
+			}
+		})
+	}
+}
+
+func TestBuiltinValues(t *testing.T) {
+	now := time.Date(2024, 5, 6, 7, 8, 9, 0, time.UTC)
+	values := BuiltinValues("goversion", now)
+
+	expected := map[string]string{
+		"date":      "2024-05-06",
+		"timestamp": "20240506070809",
+		"command":   "goversion",
+	}
+	if !reflect.DeepEqual(values, expected) {
+		t.Errorf("expected %v, got %v", expected, values)
+	}
+}
+
+func TestValidateTemplateVariables(t *testing.T) {
+	available := map[string]bool{"version": true, "major": true, "old.version": true, "timestamp": true, "git.short_sha": true, "repo.name": true}
+
+	tests := []struct {
+		name     string
+		template string
+		valid    bool
+	}{
+		{"plain variables", "repver/{{version}}-{{timestamp}}", true},
+		{"param group", "repver/{{major}}.x", true},
+		{"git built-in", "{{git.short_sha}}", true},
+		{"repo built-in", "{{ .repo.name }}", true},
+		{"previous value", "{{old.version}}", true},
+		{"unknown variable", "{{minor}}", false},
+		{"unknown previous value", "{{old.minor}}", false},
+		{"unknown git variable", "{{git.sha}}", false},
+		{"unknown namespace", "{{env.HOME}}", false},
+		{"parse error", "{{ if }}", false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := validateTemplateVariables(tc.template, available)
+			if (err == nil) != tc.valid {
+				t.Errorf("template: %q, expected valid: %v, got error: %v", tc.template, tc.valid, err)
+			}
+		})
+	}
+}
@@ -4,15 +4,20 @@ import (
 	"fmt"
 	"os"
 	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"time"
 )
 
 // Pre-compiled regex patterns for validation
 var (
-	commandNameRegex        = regexp.MustCompile(`^[a-zA-Z0-9]{1,30}$`)
-	patternAnchorRegex      = regexp.MustCompile(`^\^.*\$$`)
-	incorrectGroupSyntax    = regexp.MustCompile(`\(\?<([^>]+)>`)
-	namedGroupPatternRegex  = regexp.MustCompile(`\(\?P<([^>]+)>`)
-	transformPlaceholderRe  = regexp.MustCompile(`\{\{([^}]+)\}\}`)
+	commandNameRegex       = regexp.MustCompile(`^[a-zA-Z0-9]{1,30}$`)
+	patternAnchorRegex     = regexp.MustCompile(`^\^.*\$$`)
+	incorrectGroupSyntax   = regexp.MustCompile(`\(\?<([^>]+)>`)
+	namedGroupPatternRegex = regexp.MustCompile(`\(\?P<([^>]+)>`)
+	trailerKeyRegex        = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9-]*$`)
+	authorRegex            = regexp.MustCompile(`^[^<>]+ <[^<>]+>$`)
 )
 
 // Validate validates the RepverConfig structure
@@ -55,6 +60,11 @@ func (c *RepverCommand) Validate() error {
 		}
 	}
 
+	// Validate the monotonic policy
+	if err := validateMonotonic(c.Monotonic); err != nil {
+		return err
+	}
+
 	// Check for duplicate param names
 	paramsSeen := make(map[string]bool)
 	for _, param := range c.Params {
@@ -71,26 +81,152 @@ func (c *RepverCommand) Validate() error {
 		}
 	}
 
+	// A param or group named after a template function would call the function
+	if err := c.validateTemplateNames(); err != nil {
+		return err
+	}
+
 	// Validate transforms reference valid param groups
 	for _, target := range c.Targets {
 		if target.Transform != "" {
-			if err := c.validateTransform(target.Transform); err != nil {
+			if err := c.validateTransform(target.Transform, &target); err != nil {
 				return fmt.Errorf("invalid transform for target '%s': %s", target.Path, err)
 			}
 		}
+		if err := c.validateGroupTransforms(&target); err != nil {
+			return fmt.Errorf("invalid transforms for target '%s': %s", target.Path, err)
+		}
+	}
+
+	// Validate the source target if specified
+	if c.Source != "" {
+		source := c.GetSourceTarget()
+		if source == nil {
+			return fmt.Errorf("source '%s' must be the path of one of the command's targets", c.Source)
+		}
+		if source.Transform != "" || len(source.Transforms) > 0 {
+			return fmt.Errorf("source '%s' cannot use transform or transforms", c.Source)
+		}
+		params, err := source.GetParameterNames()
+		if err != nil {
+			return err
+		}
+		if len(params) == 0 {
+			return fmt.Errorf("source '%s' must have at least one named group that supplies a param", c.Source)
+		}
 	}
 
 	// Check if the git options are valid if any are specified
-	if c.GitOptions.GitOptionsSpecified() {
-		if err := c.GitOptions.Validate(); err != nil {
+	if err := c.GitOptions.validateTimeouts(); err != nil {
+		return err
+	}
+
+	if c.GitOptions.GitOptionsSpecified() || c.Hooks.Specified() || c.Hooks.Timeout != "" || len(c.Verify) > 0 {
+		variables, err := c.GetTemplateVariables()
+		if err != nil {
+			return err
+		}
+		if err := c.Hooks.Validate(variables, &c.GitOptions); err != nil {
 			return err
 		}
+		if err := c.validateVerify(variables); err != nil {
+			return err
+		}
+		if c.GitOptions.GitOptionsSpecified() {
+			if err := c.GitOptions.Validate(variables); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// validateTemplateNames checks that no param, or named group of a param
+// pattern, has the name of a template function. Templates such as
+// {{default}} would otherwise call the function instead of using the value.
+func (c *RepverCommand) validateTemplateNames() error {
+	names, err := c.GetParameterNames()
+	if err != nil {
+		return err
+	}
+	for _, param := range c.Params {
+		names = append(names, param.Name)
+		re, err := regexp.Compile(param.Pattern)
+		if err != nil {
+			continue
+		}
+		for i, name := range re.SubexpNames() {
+			if i > 0 && name != "" {
+				names = append(names, name)
+			}
+		}
+	}
+
+	for _, name := range names {
+		if _, ok := templateFuncs[name]; ok {
+			return fmt.Errorf("param or group '%s' has the name of a template function; use a different name", name)
+		}
+	}
+	return nil
+}
+
+// validateVerify checks that the verify commands are valid templates
+func (c *RepverCommand) validateVerify(variables map[string]bool) error {
+	for _, command := range c.Verify {
+		if strings.TrimSpace(command) == "" {
+			return fmt.Errorf("verify cannot contain an empty command")
+		}
+		if err := validateTemplateVariables(command, variables); err != nil {
+			return fmt.Errorf("verify command is not a valid template: %s", err)
+		}
 	}
 	return nil
 }
 
+// Validate validates the RepverHooks structure
+// The variables are the template variables that can be resolved for the command;
+// pre_plan commands run before planning, so they cannot use the previous values
+func (h *RepverHooks) Validate(variables map[string]bool, g *RepverGit) error {
+	if err := validateDuration(h.Timeout); err != nil {
+		return fmt.Errorf("invalid hooks timeout: %s", err)
+	}
+
+	prePlanVariables := make(map[string]bool)
+	for name := range variables {
+		if !strings.HasPrefix(name, "old.") {
+			prePlanVariables[name] = true
+		}
+	}
+
+	for _, stage := range HookStages {
+		for _, command := range h.Commands(stage) {
+			if strings.TrimSpace(command) == "" {
+				return fmt.Errorf("hooks %s cannot contain an empty command", stage)
+			}
+			stageVariables := variables
+			if stage == "pre_plan" {
+				stageVariables = prePlanVariables
+			}
+			if err := validateTemplateVariables(command, stageVariables); err != nil {
+				return fmt.Errorf("hooks %s command is not a valid template: %s", stage, err)
+			}
+		}
+	}
+
+	if len(h.PrePlan) > 0 && (g.DirtyTree == "stash" || g.DirtyTree == "worktree") {
+		return fmt.Errorf("hooks pre_plan cannot be combined with dirty_tree: %s", g.DirtyTree)
+	}
+
+	if len(h.PreCommit) > 0 && !g.Commit {
+		return fmt.Errorf("hooks pre_commit can only be set if commit is set")
+	}
+
+	return nil
+}
+
 // Validate validates the RepverGit structure
-func (g *RepverGit) Validate() error {
+// The variables are the template variables that can be resolved for the command
+func (g *RepverGit) Validate(variables map[string]bool) error {
 
 	if g.DeleteBranch && !g.CreateBranch {
 		return fmt.Errorf("delete_branch can only be set if create_branch is set")
@@ -112,6 +248,22 @@ func (g *RepverGit) Validate() error {
 		return fmt.Errorf("return_to_original_branch can only be set if create_branch is set")
 	}
 
+	if err := validateTemplateVariables(g.BranchName, variables); err != nil {
+		return fmt.Errorf("branch_name is not a valid template: %s", err)
+	}
+
+	if err := validateTemplateVariables(g.CommitMessage, variables); err != nil {
+		return fmt.Errorf("commit_message is not a valid template: %s", err)
+	}
+
+	if err := g.validateCommitOptions(variables); err != nil {
+		return err
+	}
+
+	if err := g.validateTag(variables); err != nil {
+		return err
+	}
+
 	if g.PullRequest == "" {
 		g.PullRequest = "NO"
 	}
@@ -120,6 +272,155 @@ func (g *RepverGit) Validate() error {
 		return fmt.Errorf("invalid pull_request value: %s", g.PullRequest)
 	}
 
+	if g.DirtyTree == "" {
+		g.DirtyTree = "fail"
+	}
+
+	if g.DirtyTree != "fail" && g.DirtyTree != "stash" && g.DirtyTree != "worktree" {
+		return fmt.Errorf("invalid dirty_tree value: %s", g.DirtyTree)
+	}
+
+	if g.DirtyTree == "worktree" && !g.CreateBranch {
+		return fmt.Errorf("dirty_tree: worktree can only be set if create_branch is set")
+	}
+
+	if g.BaseBranch != "" && !g.CreateBranch {
+		return fmt.Errorf("base_branch can only be set if create_branch is set")
+	}
+
+	if g.Fetch && g.BaseBranch == "" {
+		return fmt.Errorf("fetch can only be set if base_branch is set")
+	}
+
+	if g.OnExistingBranch == "" {
+		g.OnExistingBranch = "fail"
+	}
+
+	if !slices.Contains([]string{"fail", "reuse", "recreate", "suffix"}, g.OnExistingBranch) {
+		return fmt.Errorf("invalid on_existing_branch value: %s", g.OnExistingBranch)
+	}
+
+	if g.OnExistingBranch != "fail" && !g.CreateBranch {
+		return fmt.Errorf("on_existing_branch can only be set if create_branch is set")
+	}
+
+	return nil
+}
+
+// validateCommitOptions checks the commit body, trailers, signing, author and
+// sign-off, which can only be set if commit is set
+func (g *RepverGit) validateCommitOptions(variables map[string]bool) error {
+	if g.Sign == "false" {
+		g.Sign = ""
+	}
+
+	if !g.Commit && (g.CommitBody != "" || len(g.Trailers) > 0 || g.Sign != "" || g.Author != "" || g.Signoff) {
+		return fmt.Errorf("commit_body, trailers, sign, author and signoff can only be set if commit is set")
+	}
+
+	if err := validateTemplateVariables(g.CommitBody, variables); err != nil {
+		return fmt.Errorf("commit_body is not a valid template: %s", err)
+	}
+
+	for _, key := range g.trailerKeys() {
+		if !trailerKeyRegex.MatchString(key) {
+			return fmt.Errorf("invalid trailer key '%s': must contain only letters, digits and '-'", key)
+		}
+		if strings.Contains(g.Trailers[key], "\n") {
+			return fmt.Errorf("trailer '%s' must be a single line", key)
+		}
+		if err := validateTemplateVariables(g.Trailers[key], variables); err != nil {
+			return fmt.Errorf("trailer '%s' is not a valid template: %s", key, err)
+		}
+	}
+
+	if !validSign(g.Sign) {
+		return fmt.Errorf("invalid sign value: %s", g.Sign)
+	}
+
+	if g.Author != "" && !authorRegex.MatchString(g.Author) {
+		return fmt.Errorf("invalid author '%s': must be in the form 'Name <email>'", g.Author)
+	}
+
+	return nil
+}
+
+// validateTag checks the tag options, which can only be set if tag is set.
+// A tag is created on the commit of the run, so it requires commit.
+func (g *RepverGit) validateTag(variables map[string]bool) error {
+	if g.TagSign == "false" {
+		g.TagSign = ""
+	}
+
+	if !g.Tag && (g.TagName != "" || g.TagMessage != "" || g.TagSign != "" || g.PushTags) {
+		return fmt.Errorf("tag_name, tag_message, tag_sign and push_tags can only be set if tag is set")
+	}
+
+	if !g.Tag {
+		return nil
+	}
+
+	if !g.Commit {
+		return fmt.Errorf("tag can only be set if commit is set")
+	}
+
+	if g.TagName == "" {
+		return fmt.Errorf("tag_name must be set if tag is set")
+	}
+
+	if err := validateTemplateVariables(g.TagName, variables); err != nil {
+		return fmt.Errorf("tag_name is not a valid template: %s", err)
+	}
+
+	if err := validateTemplateVariables(g.TagMessage, variables); err != nil {
+		return fmt.Errorf("tag_message is not a valid template: %s", err)
+	}
+
+	if !validSign(g.TagSign) {
+		return fmt.Errorf("invalid tag_sign value: %s", g.TagSign)
+	}
+
+	if g.PushTags && g.Remote == "" {
+		return fmt.Errorf("remote must be set if push_tags is set")
+	}
+
+	return nil
+}
+
+// validSign checks a sign or tag_sign value
+func validSign(sign string) bool {
+	return slices.Contains([]string{"", "true", "gpg", "ssh"}, sign)
+}
+
+// validateTimeouts checks that timeout and every entry of timeouts is a
+// non-negative duration for a known step
+func (g *RepverGit) validateTimeouts() error {
+	if err := validateDuration(g.Timeout); err != nil {
+		return fmt.Errorf("invalid timeout: %s", err)
+	}
+	for step, value := range g.Timeouts {
+		if !slices.Contains(GitSteps, step) {
+			return fmt.Errorf("unknown step '%s' in timeouts (valid steps: %s)", step, strings.Join(GitSteps, ", "))
+		}
+		if err := validateDuration(value); err != nil {
+			return fmt.Errorf("invalid timeout for step '%s': %s", step, err)
+		}
+	}
+	return nil
+}
+
+// validateDuration checks that value is empty or a non-negative duration
+func validateDuration(value string) error {
+	if value == "" {
+		return nil
+	}
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		return err
+	}
+	if duration < 0 {
+		return fmt.Errorf("duration cannot be negative: %s", value)
+	}
 	return nil
 }
 
@@ -147,6 +448,16 @@ func (t *RepverTarget) Validate() error {
 		return fmt.Errorf("target pattern is not valid: %s", err)
 	}
 
+	// A target uses either a single transform or per-group transforms
+	if t.Transform != "" && len(t.Transforms) > 0 {
+		return fmt.Errorf("target '%s' cannot specify both transform and transforms", t.Path)
+	}
+
+	// Validate group bindings and match-only groups
+	if err := t.validateGroupRoles(); err != nil {
+		return fmt.Errorf("target '%s': %s", t.Path, err)
+	}
+
 	return nil
 }
 
@@ -309,12 +620,16 @@ func (p *RepverParam) Validate() error {
 }
 
 // validateTransform validates that a transform template only references groups
-// that are defined in the command's params patterns
-func (c *RepverCommand) validateTransform(transform string) error {
-	// Find all {{name}} patterns in the transform
-	matches := transformPlaceholderRe.FindAllStringSubmatch(transform, -1)
+// that are defined in the command's params patterns, or the previous values of
+// the target's own groups using {{old.<name>}}
+func (c *RepverCommand) validateTransform(transform string, target *RepverTarget) error {
+	// Parse the template and find every variable it references
+	variables, err := TemplateVariables(transform)
+	if err != nil {
+		return err
+	}
 
-	if len(matches) == 0 {
+	if len(variables) == 0 {
 		return fmt.Errorf("transform must contain at least one {{name}} placeholder")
 	}
 
@@ -333,18 +648,141 @@ func (c *RepverCommand) validateTransform(transform string) error {
 		}
 	}
 
+	// The built-in variables are available in every template
+	for _, name := range BuiltinVariables {
+		availableGroups[name] = true
+	}
+
+	// The previous value of each group in the target is also available
+	if target != nil {
+		groups, err := target.GetGroupNames()
+		if err != nil {
+			return err
+		}
+		for _, group := range groups {
+			availableGroups["old."+group] = true
+			availableGroups["old."+target.GetBoundParamName(group)] = true
+		}
+	}
+
 	// If no params are defined, transform cannot be used
 	if len(c.Params) == 0 {
 		return fmt.Errorf("transform requires params to be defined with named capture groups")
 	}
 
 	// Validate each placeholder references an available group
-	for _, match := range matches {
-		if len(match) > 1 {
-			groupName := match[1]
-			if !availableGroups[groupName] {
-				return fmt.Errorf("transform references unknown group '{{%s}}', available groups: %v", groupName, getMapKeys(availableGroups))
-			}
+	for _, groupName := range variables {
+		if !availableGroups[groupName] {
+			return fmt.Errorf("transform references unknown group '{{%s}}', available groups: %v", groupName, getMapKeys(availableGroups))
+		}
+	}
+
+	return nil
+}
+
+// validateGroupRoles validates the bind and match_only settings of a target.
+// Bindings and match-only entries must reference named groups in the pattern,
+// and a group can only get its value from one place.
+func (t *RepverTarget) validateGroupRoles() error {
+	if len(t.Bind) == 0 && len(t.MatchOnly) == 0 {
+		return nil
+	}
+
+	groups, err := t.GetGroupNames()
+	if err != nil {
+		return err
+	}
+
+	groupSet := make(map[string]bool)
+	for _, group := range groups {
+		groupSet[group] = true
+	}
+
+	boundGroups := make([]string, 0, len(t.Bind))
+	for group := range t.Bind {
+		boundGroups = append(boundGroups, group)
+	}
+	sort.Strings(boundGroups)
+
+	for _, group := range boundGroups {
+		paramName := t.Bind[group]
+		if !groupSet[group] {
+			return fmt.Errorf("bind references unknown group '%s', available groups: %v", group, groups)
+		}
+		if !commandNameRegex.MatchString(paramName) {
+			return fmt.Errorf("group '%s' is bound to invalid param name '%s': param name must be alphanumeric and between 1 and 30 characters", group, paramName)
+		}
+		if t.Transform != "" {
+			return fmt.Errorf("group '%s' cannot be bound when transform is specified", group)
+		}
+		if _, ok := t.Transforms[group]; ok {
+			return fmt.Errorf("group '%s' cannot be both bound and transformed", group)
+		}
+	}
+
+	seen := make(map[string]bool)
+	for _, group := range t.MatchOnly {
+		if !groupSet[group] {
+			return fmt.Errorf("match_only references unknown group '%s', available groups: %v", group, groups)
+		}
+		if seen[group] {
+			return fmt.Errorf("duplicate match_only group: %s", group)
+		}
+		seen[group] = true
+		if _, ok := t.Bind[group]; ok {
+			return fmt.Errorf("group '%s' cannot be both match-only and bound", group)
+		}
+		if _, ok := t.Transforms[group]; ok {
+			return fmt.Errorf("group '%s' cannot be both match-only and transformed", group)
+		}
+	}
+
+	return nil
+}
+
+// validateGroupTransforms validates the per-group transforms of a target.
+// Every transform must reference a named group in the target's pattern, and
+// every group in the pattern must have either a transform or a matching param.
+func (c *RepverCommand) validateGroupTransforms(t *RepverTarget) error {
+	if len(t.Transforms) == 0 {
+		return nil
+	}
+
+	groups, err := t.GetGroupNames()
+	if err != nil {
+		return err
+	}
+
+	groupSet := make(map[string]bool)
+	for _, group := range groups {
+		groupSet[group] = true
+	}
+
+	transformGroups := make([]string, 0, len(t.Transforms))
+	for group := range t.Transforms {
+		transformGroups = append(transformGroups, group)
+	}
+	sort.Strings(transformGroups)
+
+	for _, group := range transformGroups {
+		transform := t.Transforms[group]
+		if !groupSet[group] {
+			return fmt.Errorf("transform for unknown group '%s', available groups: %v", group, groups)
+		}
+		if err := c.validateTransform(transform, t); err != nil {
+			return fmt.Errorf("group '%s': %s", group, err)
+		}
+	}
+
+	for _, group := range groups {
+		if _, ok := t.Transforms[group]; ok {
+			continue
+		}
+		if t.IsMatchOnly(group) {
+			continue
+		}
+		if c.GetParam(t.GetBoundParamName(group)) == nil {
+			return fmt.Errorf("group '%s' must have either a transform or a matching param", group)
 		}
 	}
 
@@ -357,5 +795,6 @@ func getMapKeys(m map[string]bool) []string {
 	for k := range m {
 		keys = append(keys, k)
 	}
+	sort.Strings(keys)
 	return keys
 }
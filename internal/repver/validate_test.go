@@ -1,6 +1,11 @@
 package repver
 
-import "testing"
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
 
 func TestValidateCommandName(t *testing.T) {
 	tests := []struct {
@@ -207,6 +212,39 @@ func TestValidateTransform(t *testing.T) {
 			"{{major}}.{{minor}}",
 			false,
 		},
+		{
+			"template transform with valid groups",
+			RepverCommand{
+				Name: "test",
+				Params: []RepverParam{
+					{Name: "version", Pattern: `^v?(?P<major>\d+)\.(?P<minor>\d+)$`},
+				},
+			},
+			"{{ .major }}.x",
+			true,
+		},
+		{
+			"template transform with unknown group",
+			RepverCommand{
+				Name: "test",
+				Params: []RepverParam{
+					{Name: "version", Pattern: `^(?P<major>\d+)\.(?P<minor>\d+)$`},
+				},
+			},
+			"{{ upper .patch }}",
+			false,
+		},
+		{
+			"template transform with parse error",
+			RepverCommand{
+				Name: "test",
+				Params: []RepverParam{
+					{Name: "version", Pattern: `^(?P<major>\d+)\.(?P<minor>\d+)$`},
+				},
+			},
+			"{{ if .major }}",
+			false,
+		},
 		{
 			"transform without placeholders",
 			RepverCommand{
@@ -222,7 +260,7 @@ func TestValidateTransform(t *testing.T) {
 
 	for _, tc := range tests {
 		t.Run(tc.name, func(t *testing.T) {
-			err := tc.command.validateTransform(tc.transform)
+			err := tc.command.validateTransform(tc.transform, nil)
 			if (err == nil) != tc.valid {
 				t.Errorf("transform: %q, expected valid: %v, got error: %v", tc.transform, tc.valid, err)
 			}
@@ -362,10 +400,589 @@ func TestApplyTransform(t *testing.T) {
 
 	for _, tc := range tests {
 		t.Run(tc.name, func(t *testing.T) {
-			result := ApplyTransform(tc.template, tc.groups)
+			result, err := ApplyTransform(tc.template, tc.groups)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
 			if result != tc.expected {
 				t.Errorf("expected %q, got %q", tc.expected, result)
 			}
 		})
 	}
 }
+
+func TestValidateGroupTransforms(t *testing.T) {
+	params := []RepverParam{
+		{Name: "version", Pattern: `^(?P<major>\d+)\.(?P<minor>\d+)\.(?P<patch>\d+)$`},
+		{Name: "alpine", Pattern: `^\d+\.\d+$`},
+	}
+
+	tests := []struct {
+		name   string
+		target RepverTarget
+		valid  bool
+	}{
+		{
+			"every group has a transform",
+			RepverTarget{
+				Pattern:    `^golang:(?P<ver>.*)-alpine(?P<os>.*)$`,
+				Transforms: map[string]string{"ver": "{{major}}.{{minor}}", "os": "{{major}}"},
+			},
+			true,
+		},
+		{
+			"group without transform has matching param",
+			RepverTarget{
+				Pattern:    `^golang:(?P<ver>.*)-alpine(?P<alpine>.*)$`,
+				Transforms: map[string]string{"ver": "{{major}}.{{minor}}"},
+			},
+			true,
+		},
+		{
+			"group without transform or param",
+			RepverTarget{
+				Pattern:    `^golang:(?P<ver>.*)-alpine(?P<os>.*)$`,
+				Transforms: map[string]string{"ver": "{{major}}.{{minor}}"},
+			},
+			false,
+		},
+		{
+			"transform for unknown group",
+			RepverTarget{
+				Pattern:    `^golang:(?P<ver>.*)$`,
+				Transforms: map[string]string{"ver": "{{major}}", "other": "{{minor}}"},
+			},
+			false,
+		},
+		{
+			"transform references unknown param group",
+			RepverTarget{
+				Pattern:    `^golang:(?P<ver>.*)$`,
+				Transforms: map[string]string{"ver": "{{build}}"},
+			},
+			false,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			command := RepverCommand{Name: "test", Params: params}
+			err := command.validateGroupTransforms(&tc.target)
+			if (err == nil) != tc.valid {
+				t.Errorf("target: %+v, expected valid: %v, got error: %v", tc.target, tc.valid, err)
+			}
+		})
+	}
+}
+
+func TestValidateGroupRoles(t *testing.T) {
+	tests := []struct {
+		name   string
+		target RepverTarget
+		valid  bool
+	}{
+		{
+			"bind to param",
+			RepverTarget{Pattern: `^go (?P<version>.*)$`, Bind: map[string]string{"version": "goversion"}},
+			true,
+		},
+		{
+			"match-only guard",
+			RepverTarget{Pattern: `^(?P<tool>\w+) (?P<version>.*)$`, MatchOnly: []string{"tool"}},
+			true,
+		},
+		{
+			"bind unknown group",
+			RepverTarget{Pattern: `^go (?P<version>.*)$`, Bind: map[string]string{"ver": "goversion"}},
+			false,
+		},
+		{
+			"bind to invalid param name",
+			RepverTarget{Pattern: `^go (?P<version>.*)$`, Bind: map[string]string{"version": "go-version"}},
+			false,
+		},
+		{
+			"bind with transform",
+			RepverTarget{Pattern: `^go (?P<version>.*)$`, Bind: map[string]string{"version": "goversion"}, Transform: "{{major}}"},
+			false,
+		},
+		{
+			"match-only unknown group",
+			RepverTarget{Pattern: `^go (?P<version>.*)$`, MatchOnly: []string{"tool"}},
+			false,
+		},
+		{
+			"match-only and bound",
+			RepverTarget{Pattern: `^go (?P<version>.*)$`, MatchOnly: []string{"version"}, Bind: map[string]string{"version": "goversion"}},
+			false,
+		},
+		{
+			"match-only and transformed",
+			RepverTarget{Pattern: `^go (?P<version>.*)$`, MatchOnly: []string{"version"}, Transforms: map[string]string{"version": "{{major}}"}},
+			false,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.target.validateGroupRoles()
+			if (err == nil) != tc.valid {
+				t.Errorf("target: %+v, expected valid: %v, got error: %v", tc.target, tc.valid, err)
+			}
+		})
+	}
+}
+
+func TestValidateGitTemplates(t *testing.T) {
+	command := RepverCommand{
+		Name: "test",
+		Params: []RepverParam{
+			{Name: "version", Pattern: `^(?P<major>\d+)\.(?P<minor>\d+)$`},
+		},
+		Targets: []RepverTarget{
+			{Path: "go.mod", Pattern: `^go (?P<version>.*)$`},
+		},
+	}
+
+	tests := []struct {
+		name  string
+		git   RepverGit
+		valid bool
+	}{
+		{
+			"param and groups",
+			RepverGit{CreateBranch: true, BranchName: "repver/{{major}}.{{minor}}", Commit: true, CommitMessage: "Update to {{version}}"},
+			true,
+		},
+		{
+			"previous value and built-in",
+			RepverGit{CreateBranch: true, BranchName: "repver/{{version}}-{{timestamp}}", Commit: true, CommitMessage: "Update from {{old.version}} on {{git.branch}}"},
+			true,
+		},
+		{
+			"unknown placeholder in branch name",
+			RepverGit{CreateBranch: true, BranchName: "repver/{{patch}}"},
+			false,
+		},
+		{
+			"unknown placeholder in commit message",
+			RepverGit{Commit: true, CommitMessage: "Update to {{ .release }}"},
+			false,
+		},
+		{
+			"invalid template",
+			RepverGit{Commit: true, CommitMessage: "Update to {{ .version"},
+			false,
+		},
+		{
+			"stash dirty tree",
+			RepverGit{Commit: true, CommitMessage: "Update", DirtyTree: "stash"},
+			true,
+		},
+		{
+			"worktree dirty tree",
+			RepverGit{CreateBranch: true, BranchName: "repver/{{version}}", DirtyTree: "worktree"},
+			true,
+		},
+		{
+			"worktree dirty tree without branch",
+			RepverGit{Commit: true, CommitMessage: "Update", DirtyTree: "worktree"},
+			false,
+		},
+		{
+			"base branch with fetch",
+			RepverGit{CreateBranch: true, BranchName: "repver/{{version}}", BaseBranch: "origin/main", Fetch: true},
+			true,
+		},
+		{
+			"base branch without create branch",
+			RepverGit{Commit: true, CommitMessage: "Update", BaseBranch: "main"},
+			false,
+		},
+		{
+			"fetch without base branch",
+			RepverGit{CreateBranch: true, BranchName: "repver/{{version}}", Fetch: true},
+			false,
+		},
+		{
+			"invalid dirty tree",
+			RepverGit{Commit: true, CommitMessage: "Update", DirtyTree: "ignore"},
+			false,
+		},
+		{
+			"suffix existing branch",
+			RepverGit{CreateBranch: true, BranchName: "repver/{{version}}", OnExistingBranch: "suffix"},
+			true,
+		},
+		{
+			"reuse existing branch without create branch",
+			RepverGit{Commit: true, CommitMessage: "Update", OnExistingBranch: "reuse"},
+			false,
+		},
+		{
+			"invalid existing branch policy",
+			RepverGit{CreateBranch: true, BranchName: "repver/{{version}}", OnExistingBranch: "merge"},
+			false,
+		},
+		{
+			"commit options",
+			RepverGit{Commit: true, CommitMessage: "Update", CommitBody: "From {{old.version}}\nto {{version}}", Trailers: map[string]string{"Co-authored-by": "Bot <bot@example.com>", "Release": "{{major}}"}, Sign: "ssh", Author: "Bot <bot@example.com>", Signoff: true},
+			true,
+		},
+		{
+			"sign false",
+			RepverGit{Commit: true, CommitMessage: "Update", Sign: "false"},
+			true,
+		},
+		{
+			"signoff without commit",
+			RepverGit{CreateBranch: true, BranchName: "repver/{{version}}", Signoff: true},
+			false,
+		},
+		{
+			"unknown variable in commit body",
+			RepverGit{Commit: true, CommitMessage: "Update", CommitBody: "Update {{unknown}}"},
+			false,
+		},
+		{
+			"invalid trailer key",
+			RepverGit{Commit: true, CommitMessage: "Update", Trailers: map[string]string{"Co authored by": "Bot <bot@example.com>"}},
+			false,
+		},
+		{
+			"multi-line trailer",
+			RepverGit{Commit: true, CommitMessage: "Update", Trailers: map[string]string{"Note": "one\ntwo"}},
+			false,
+		},
+		{
+			"invalid sign",
+			RepverGit{Commit: true, CommitMessage: "Update", Sign: "x509"},
+			false,
+		},
+		{
+			"invalid author",
+			RepverGit{Commit: true, CommitMessage: "Update", Author: "bot@example.com"},
+			false,
+		},
+		{
+			"tag",
+			RepverGit{Commit: true, CommitMessage: "Update", Push: true, Remote: "origin", Tag: true, TagName: "v{{version}}", TagMessage: "Release {{major}}.{{minor}}", TagSign: "true", PushTags: true},
+			true,
+		},
+		{
+			"tag without commit",
+			RepverGit{CreateBranch: true, BranchName: "repver/{{version}}", Tag: true, TagName: "v{{version}}"},
+			false,
+		},
+		{
+			"tag without name",
+			RepverGit{Commit: true, CommitMessage: "Update", Tag: true},
+			false,
+		},
+		{
+			"tag name with unknown variable",
+			RepverGit{Commit: true, CommitMessage: "Update", Tag: true, TagName: "v{{release}}"},
+			false,
+		},
+		{
+			"push tags without tag",
+			RepverGit{Commit: true, CommitMessage: "Update", Remote: "origin", PushTags: true},
+			false,
+		},
+		{
+			"push tags without remote",
+			RepverGit{Commit: true, CommitMessage: "Update", Tag: true, TagName: "v{{version}}", PushTags: true},
+			false,
+		},
+		{
+			"invalid tag sign",
+			RepverGit{Commit: true, CommitMessage: "Update", Tag: true, TagName: "v{{version}}", TagSign: "x509"},
+			false,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			variables, err := command.GetTemplateVariables()
+			if err != nil {
+				t.Fatalf("GetTemplateVariables returned error: %v", err)
+			}
+			err = tc.git.Validate(variables)
+			if (err == nil) != tc.valid {
+				t.Errorf("git: %+v, expected valid: %v, got error: %v", tc.git, tc.valid, err)
+			}
+		})
+	}
+}
+
+func TestValidateGitTimeouts(t *testing.T) {
+	tests := []struct {
+		name  string
+		git   RepverGit
+		valid bool
+	}{
+		{"unset", RepverGit{}, true},
+		{"default and per step", RepverGit{Timeout: "2m", Timeouts: map[string]string{"push": "10m", "query": "0"}}, true},
+		{"invalid default", RepverGit{Timeout: "soon"}, false},
+		{"negative default", RepverGit{Timeout: "-1s"}, false},
+		{"unknown step", RepverGit{Timeouts: map[string]string{"merge": "1m"}}, false},
+		{"invalid step", RepverGit{Timeouts: map[string]string{"push": "10"}}, false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.git.validateTimeouts()
+			if (err == nil) != tc.valid {
+				t.Errorf("git: %+v, expected valid: %v, got error: %v", tc.git, tc.valid, err)
+			}
+		})
+	}
+}
+
+func TestStepTimeout(t *testing.T) {
+	g := RepverGit{Timeout: "2m", Timeouts: map[string]string{"push": "10m", "query": "0"}}
+	if got := g.StepTimeout("push"); got != 10*time.Minute {
+		t.Errorf("expected push timeout of 10m, got %v", got)
+	}
+	if got := g.StepTimeout("query"); got != 0 {
+		t.Errorf("expected no query timeout, got %v", got)
+	}
+	if got := g.StepTimeout("commit"); got != 2*time.Minute {
+		t.Errorf("expected commit timeout of 2m, got %v", got)
+	}
+	if got := (&RepverGit{}).StepTimeout("commit"); got != DefaultGitTimeout {
+		t.Errorf("expected default timeout, got %v", got)
+	}
+}
+
+func TestBuildCommitMessage(t *testing.T) {
+	g := RepverGit{
+		CommitMessage: "Update Go to {{version}}",
+		CommitBody:    "Updated from {{old.version}}.\n\nGenerated by repver.\n",
+		Trailers:      map[string]string{"Release": "v{{version}}", "Co-authored-by": "Bot <bot@example.com>"},
+	}
+	message, err := g.BuildCommitMessage(map[string]string{"version": "1.23.0", "old.version": "1.22.3"})
+	if err != nil {
+		t.Fatalf("BuildCommitMessage returned error: %v", err)
+	}
+
+	expected := "Update Go to 1.23.0\n\nUpdated from 1.22.3.\n\nGenerated by repver.\n\nCo-authored-by: Bot <bot@example.com>\nRelease: v1.23.0"
+	if message != expected {
+		t.Errorf("unexpected commit message:\n got: %q\nwant: %q", message, expected)
+	}
+
+	g = RepverGit{CommitMessage: "Update Go to {{version}}"}
+	if message, _ := g.BuildCommitMessage(map[string]string{"version": "1.23.0"}); message != "Update Go to 1.23.0" {
+		t.Errorf("expected only the subject, got %q", message)
+	}
+}
+
+func TestValidateSource(t *testing.T) {
+	tests := []struct {
+		name    string
+		source  string
+		targets []RepverTarget
+		valid   bool
+	}{
+		{
+			"source is a target",
+			".go-version",
+			[]RepverTarget{{Path: ".go-version", Pattern: `^(?P<version>.*)$`}},
+			true,
+		},
+		{
+			"source is not a target",
+			".nvmrc",
+			[]RepverTarget{{Path: ".go-version", Pattern: `^(?P<version>.*)$`}},
+			false,
+		},
+		{
+			"source uses a transform",
+			".go-version",
+			[]RepverTarget{{Path: ".go-version", Pattern: `^(?P<version>.*)$`, Transform: "{{major}}"}},
+			false,
+		},
+		{
+			"source has only match-only groups",
+			".go-version",
+			[]RepverTarget{{Path: ".go-version", Pattern: `^(?P<version>.*)$`, MatchOnly: []string{"version"}}},
+			false,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for _, target := range tc.targets {
+				if err := os.WriteFile(filepath.Join(dir, target.Path), []byte("1.0.0\n"), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			t.Chdir(dir)
+
+			command := RepverCommand{
+				Name:    "test",
+				Source:  tc.source,
+				Params:  []RepverParam{{Name: "version", Pattern: `^(?P<major>\d+)\.\d+\.\d+$`}},
+				Targets: tc.targets,
+			}
+			err := command.Validate()
+			if (err == nil) != tc.valid {
+				t.Errorf("expected valid: %v, got error: %v", tc.valid, err)
+			}
+		})
+	}
+}
+
+func TestValidateTemplateNames(t *testing.T) {
+	tests := []struct {
+		name    string
+		params  []RepverParam
+		targets []RepverTarget
+		valid   bool
+	}{
+		{
+			"no clash",
+			[]RepverParam{{Name: "version", Pattern: `^(?P<major>\d+)\.\d+$`}},
+			[]RepverTarget{{Path: "go.mod", Pattern: `^go (?P<version>.*)$`}},
+			true,
+		},
+		{
+			"target group named after a function",
+			nil,
+			[]RepverTarget{{Path: "go.mod", Pattern: `^go (?P<default>.*)$`}},
+			false,
+		},
+		{
+			"param group named after a function",
+			[]RepverParam{{Name: "version", Pattern: `^(?P<upper>\d+)\.\d+$`}},
+			[]RepverTarget{{Path: "go.mod", Pattern: `^go (?P<version>.*)$`}},
+			false,
+		},
+		{
+			"bound param named after a function",
+			nil,
+			[]RepverTarget{{Path: "go.mod", Pattern: `^go (?P<version>.*)$`, Bind: map[string]string{"version": "now"}}},
+			false,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			command := RepverCommand{Name: "test", Params: tc.params, Targets: tc.targets}
+			err := command.validateTemplateNames()
+			if (err == nil) != tc.valid {
+				t.Errorf("expected valid: %v, got error: %v", tc.valid, err)
+			}
+		})
+	}
+}
+
+func TestValidateHooks(t *testing.T) {
+	tests := []struct {
+		name  string
+		hooks RepverHooks
+		git   RepverGit
+		valid bool
+	}{
+		{
+			"all stages",
+			RepverHooks{PrePlan: []string{"go mod download"}, PostApply: []string{"go mod tidy"}, PreCommit: []string{"go generate ./... # {{old.version}}"}, Timeout: "2m"},
+			RepverGit{Commit: true, CommitMessage: "Update"},
+			true,
+		},
+		{
+			"without git",
+			RepverHooks{PostApply: []string{"go mod tidy"}},
+			RepverGit{},
+			true,
+		},
+		{
+			"empty command",
+			RepverHooks{PostApply: []string{" "}},
+			RepverGit{},
+			false,
+		},
+		{
+			"unknown variable",
+			RepverHooks{PostApply: []string{"echo {{release}}"}},
+			RepverGit{},
+			false,
+		},
+		{
+			"previous value in pre_plan",
+			RepverHooks{PrePlan: []string{"echo {{old.version}}"}},
+			RepverGit{},
+			false,
+		},
+		{
+			"pre_plan with stash",
+			RepverHooks{PrePlan: []string{"go mod download"}},
+			RepverGit{Commit: true, CommitMessage: "Update", DirtyTree: "stash"},
+			false,
+		},
+		{
+			"pre_commit without commit",
+			RepverHooks{PreCommit: []string{"go mod tidy"}},
+			RepverGit{},
+			false,
+		},
+		{
+			"invalid timeout",
+			RepverHooks{PostApply: []string{"go mod tidy"}, Timeout: "soon"},
+			RepverGit{},
+			false,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			command := RepverCommand{
+				Name:       "test",
+				Params:     []RepverParam{{Name: "version", Pattern: `^(?P<major>\d+)\.\d+\.\d+$`}},
+				Targets:    []RepverTarget{{Path: "go.mod", Pattern: `^go (?P<version>.*)$`}},
+				Hooks:      tc.hooks,
+				GitOptions: tc.git,
+			}
+			variables, err := command.GetTemplateVariables()
+			if err != nil {
+				t.Fatalf("GetTemplateVariables returned error: %v", err)
+			}
+			err = command.Hooks.Validate(variables, &command.GitOptions)
+			if (err == nil) != tc.valid {
+				t.Errorf("hooks: %+v, expected valid: %v, got error: %v", tc.hooks, tc.valid, err)
+			}
+		})
+	}
+}
+
+func TestValidateVerify(t *testing.T) {
+	tests := []struct {
+		name   string
+		verify []string
+		valid  bool
+	}{
+		{"commands", []string{"go build ./...", "make test VERSION={{version}} OLD={{old.version}}"}, true},
+		{"empty command", []string{"go build ./...", ""}, false},
+		{"unknown variable", []string{"make test VERSION={{release}}"}, false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			command := RepverCommand{
+				Name:    "test",
+				Params:  []RepverParam{{Name: "version", Pattern: `^(?P<major>\d+)\.\d+\.\d+$`}},
+				Targets: []RepverTarget{{Path: "go.mod", Pattern: `^go (?P<version>.*)$`}},
+				Verify:  tc.verify,
+			}
+			variables, err := command.GetTemplateVariables()
+			if err != nil {
+				t.Fatalf("GetTemplateVariables returned error: %v", err)
+			}
+			err = command.validateVerify(variables)
+			if (err == nil) != tc.valid {
+				t.Errorf("verify: %v, expected valid: %v, got error: %v", tc.verify, tc.valid, err)
+			}
+		})
+	}
+}
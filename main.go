@@ -1,15 +1,25 @@
 package main
 
 import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
+	"io"
 	"maps"
 	"os"
+	"os/signal"
+	"path/filepath"
 	"regexp"
 	"runtime"
 	"runtime/debug"
+	"slices"
 	"sort"
 	"strings"
+	"syscall"
+	"time"
 
 	"github.com/UnitVectorY-Labs/repver/internal/color"
 	"github.com/UnitVectorY-Labs/repver/internal/git"
@@ -42,19 +52,18 @@ func main() {
 		}
 	}
 
-	// Pre-parse static flags to handle --version and --exists before loading .repver
+	// Pre-parse static flags to handle --version and --exists before loading .repver.
+	// Errors are reported by the full parse, once the param-* flags are known.
 	preParse := flag.NewFlagSet("preparse", flag.ContinueOnError)
-	preParse.SetOutput(os.Stderr)
-	preCommand := preParse.String("command", "", "Command to execute")
-	preExists := preParse.Bool("exists", false, "Check whether .repver exists and contains the specified command")
-	preVersion := preParse.Bool("version", false, "Print version")
-	preDebug := preParse.Bool("debug", false, "Enable debug mode")
-	preDryRun := preParse.Bool("dry-run", false, "Dry run mode")
-	preNoColor := preParse.Bool("no-color", false, "Disable colored output")
+	preParse.SetOutput(io.Discard)
+	pre := repver.RegisterFlags(preParse)
+
+	// Skip over a leading subcommand so its flags are still pre-parsed
+	_, preArgs := repver.SplitSubcommand(os.Args[1:])
 
 	// Register param-* flags dynamically to avoid unknown flag errors during pre-parse
 	// We'll accept any --param-* flags here but not use them
-	for _, arg := range os.Args[1:] {
+	for _, arg := range preArgs {
 		if strings.HasPrefix(arg, "--param-") {
 			parts := strings.SplitN(arg, "=", 2)
 			paramName := strings.TrimPrefix(parts[0], "--")
@@ -65,27 +74,32 @@ func main() {
 	}
 
 	// Parse pre-parse flags - errors are handled by falling through to normal mode
-	_ = preParse.Parse(os.Args[1:])
+	_ = preParse.Parse(preArgs)
 
 	// Handle --version early
-	if *preVersion {
+	if *pre.Version {
 		fmt.Println(buildVersionOutput(Version))
 		return
 	}
 
 	// Handle --exists mode
-	if *preExists {
-		handleExistsMode(*preCommand)
+	if *pre.Exists {
+		handleExistsMode(*pre.Command)
 		return
 	}
 
+	// Switch to JSON output before loading .repver so its errors are reported too
+	if *pre.Output == "json" {
+		enableJSONOutput()
+	}
+
 	// Set debug and dry-run from pre-parse for early debugging
-	repver.Debug = *preDebug
-	repver.DryRun = *preDryRun
+	repver.Debug = *pre.Debug
+	repver.DryRun = *pre.DryRun
 
 	// Disable color if --no-color flag was passed (NO_COLOR env var is
 	// handled by the color package init function)
-	if *preNoColor {
+	if *pre.NoColor {
 		color.Enabled = false
 	}
 	repver.NoColor = !color.Enabled
@@ -126,10 +140,11 @@ func main() {
 		argumentFlags[argumentName] = flag.String("param-"+argumentName, "", "Value for "+argumentName)
 	}
 
-	showVersion := flag.Bool("version", false, "Print version")
-
 	// Process: Parse command line arguments
-	repver.ParseParams()
+	flags := repver.ParseParams()
+
+	// Git and gh may only prompt for credentials when a user can answer
+	client := git.NewExecClient(repver.IsInteractive())
 
 	// Sync color state after full parse in case the pre-parse could not
 	// process --no-color (e.g. flag parsing stopped early on unknown flags).
@@ -137,11 +152,59 @@ func main() {
 		color.Enabled = false
 	}
 
-	if *showVersion {
+	if *flags.Version {
+		disableJSONOutput()
 		fmt.Println(buildVersionOutput(Version))
 		return
 	}
 
+	// Decision: Output format valid?
+	switch repver.Output {
+	case "json":
+		enableJSONOutput()
+	case "text":
+		disableJSONOutput()
+	default:
+		printErrorAndExit(113, fmt.Sprintf("Invalid output format '%s' (must be text or json)", repver.Output))
+	}
+
+	// Decision: Diff context valid?
+	if repver.DiffContext < 0 {
+		printErrorAndExit(116, fmt.Sprintf("Invalid diff context '%d' (must be zero or more lines)", repver.DiffContext))
+	}
+
+	// Decision: Help requested?
+	if repver.Subcommand == "help" || repver.Help {
+		disableJSONOutput()
+		handleHelpMode(config)
+		return
+	}
+
+	// Decision: Check mode requested?
+	if repver.Subcommand == "check" || repver.Check {
+		disableJSONOutput()
+		handleCheckMode(config)
+		return
+	}
+
+	// Decision: Get mode requested?
+	if repver.Subcommand == "get" {
+		disableJSONOutput()
+		handleGetMode(config)
+		return
+	}
+
+	// Decision: Apply saved plan?
+	if repver.Subcommand == "apply" {
+		handleApplyMode(client)
+		return
+	}
+
+	// Decision: Subcommand known?
+	if repver.Subcommand != "" && !repver.IsSubcommand(repver.Subcommand) {
+		printErrorAndExit(111, fmt.Sprintf("Unknown subcommand '%s'", repver.Subcommand), generateHelpMessage(config))
+	}
+
 	// Decision: Command specified?
 	if repver.UserCommand == "" {
 		// Generate help message listing all available commands with their parameters
@@ -157,6 +220,7 @@ func main() {
 		helpMessage := generateHelpMessage(config)
 		printErrorAndExit(104, "Command not found", helpMessage)
 	}
+	report.Command = command.Name
 
 	// Process: Identify required arguments for command]
 	parameters, err := command.GetParameterNames()
@@ -166,8 +230,29 @@ func main() {
 		printErrorAndExit(502, "Internal error compiling prevalidated parameters")
 	}
 
+	// Decision: Sync from source?
+	if repver.Subcommand == "sync" {
+		if command.Source == "" {
+			printErrorAndExit(114, fmt.Sprintf("Command '%s' has no source", command.Name))
+		}
+
+		// Process: Read params from source
+		sourceValues, err := command.GetSourceValues()
+		if err != nil {
+			printErrorAndExit(115, fmt.Sprintf("Failed to read values from source: %v", err))
+		}
+		for name, value := range sourceValues {
+			// Values passed explicitly on the command line take precedence
+			if val, ok := argumentFlags[name]; ok && *val == "" {
+				*val = value
+			}
+		}
+		repver.Debugln("Read params from source '%s': %v", command.Source, sourceValues)
+	}
+
 	// Decision: All params provided?
 	argumentValues := make(map[string]string)
+	report.Params = argumentValues
 	missingParams := []string{}
 	for _, parameter := range parameters {
 		// Check if the parameter is set
@@ -178,6 +263,19 @@ func main() {
 		}
 	}
 
+	// Process: Prompt for missing params when running interactively
+	if len(missingParams) > 0 && repver.IsInteractive() {
+		reader := bufio.NewReader(os.Stdin)
+		for _, parameter := range missingParams {
+			value, err := repver.PromptForParam(reader, os.Stderr, parameter, command.GetParam(parameter), command.GetCurrentParamValue(parameter))
+			if err != nil {
+				printErrorAndExit(105, fmt.Sprintf("Missing required parameters: %v", err))
+			}
+			argumentValues[parameter] = value
+		}
+		missingParams = nil
+	}
+
 	if len(missingParams) > 0 {
 		// Create a targeted help message for the specific command
 		var helpBuilder strings.Builder
@@ -198,6 +296,7 @@ func main() {
 
 	// Process: Validate params and extract named groups
 	extractedGroups := make(map[string]string)
+	report.Groups = extractedGroups
 	for _, param := range command.Params {
 		value, exists := argumentValues[param.Name]
 		if exists {
@@ -216,13 +315,46 @@ func main() {
 		}
 	}
 
+	// Process: Collect built-in template values and make them available to transforms
+	builtinValues := collectBuiltinValues(client, command)
+	transformValues := maps.Clone(builtinValues)
+	maps.Copy(transformValues, extractedGroups)
+
+	// Process: Render hooks
+	hooks := newHookRunner(&command.Hooks, command.Name, argumentValues, extractedGroups)
+	prePlanValues := maps.Clone(transformValues)
+	maps.Copy(prePlanValues, argumentValues)
+	hooks.commands["pre_plan"], err = command.Hooks.RenderCommands("pre_plan", prePlanValues)
+	if err != nil {
+		printErrorAndExit(203, fmt.Sprintf("Failed to render template: %v", err))
+	}
+
+	// Decision: pre_plan hooks?
+	// A saved plan or patch cannot carry the files the hooks change, so the hooks
+	// are not run when writing one; a saved plan runs them when it is applied
+	if repver.DryRun {
+		hooks.preview("pre_plan")
+	} else if hooks.has("pre_plan") && (repver.Subcommand == "plan" || repver.PatchOut != "") {
+		written := "patch"
+		if repver.Subcommand == "plan" {
+			written = "plan"
+		}
+		for _, command := range hooks.commands["pre_plan"] {
+			fmt.Println(color.Yellowf("Skipping pre_plan hook while writing a %s: %s", written, command))
+		}
+	} else if hooks.has("pre_plan") {
+		if err := runPrePlanHooks(client, hooks, &command.GitOptions, nil); err != nil {
+			exitWithError(err)
+		}
+	}
+
 	// Evaluate all target changes before performing any git operations so a no-op
 	// leaves the repository untouched.
 	executionPlans := make([]*repver.ExecutionPlan, 0, len(command.Targets))
 	anyFileModified := false
 	commitFiles := []string{}
 	for _, target := range command.Targets {
-		plan, err := target.Plan(argumentValues, extractedGroups)
+		plan, err := target.Plan(argumentValues, transformValues)
 		if err != nil {
 			printErrorAndExit(202, "Failed to evaluate command on target")
 		}
@@ -233,38 +365,272 @@ func main() {
 			commitFiles = append(commitFiles, target.Path)
 		}
 	}
+	report.Plans = executionPlans
+
+	// Decision: Downgrade allowed?
+	skipped, err := command.CheckMonotonic(executionPlans)
+	for _, note := range skipped {
+		fmt.Fprintln(os.Stderr, color.Yellowf("Warning: %s", note))
+	}
+	if err != nil {
+		if !repver.AllowDowngrade {
+			printErrorAndExit(110, "Downgrade detected", fmt.Sprintf("%v\n\nUse --allow-downgrade to apply these changes anyway.", err))
+		}
+		fmt.Println(color.Yellowf("Warning: %v", err))
+	}
+
+	// Process: Collect template values for branch names and commit messages. These
+	// receive the same values as transforms plus the params and the previous
+	// values replaced in each target
+	templateValues := maps.Clone(transformValues)
+	maps.Copy(templateValues, argumentValues)
+	maps.Copy(templateValues, repver.OldTemplateValues(executionPlans))
+
+	// Decision: Export patch?
+	if repver.PatchOut != "" {
+		// Process: Write patch without modifying the target files
+		patch := repver.BuildPatch(executionPlans, repver.DiffContext)
+		if err := os.WriteFile(repver.PatchOut, []byte(patch), 0644); err != nil {
+			printErrorAndExit(117, fmt.Sprintf("Failed to write patch: %v", err))
+		}
+		report.PatchOut = repver.PatchOut
+
+		if anyFileModified {
+			if repver.Diff {
+				fmt.Print(repver.ColorizeDiff(patch))
+			}
+			fmt.Println(color.Greenf("Wrote patch for %d file(s) to %s", len(commitFiles), repver.PatchOut))
+			writeReport(statusSuccess, 0, "")
+			return
+		}
+	}
+
+	// Process: Render branch name, commit message and tag
+	branchName := ""
+	commitMessage := ""
+	tagName := ""
+	tagMessage := ""
+	if anyFileModified {
+		if command.GitOptions.CreateBranch {
+			branchName, err = command.GitOptions.BuildBranchName(templateValues)
+			if err != nil {
+				printErrorAndExit(203, fmt.Sprintf("Failed to render template: %v", err))
+			}
+		}
+		if command.GitOptions.Commit {
+			commitMessage, err = command.GitOptions.BuildCommitMessage(templateValues)
+			if err != nil {
+				printErrorAndExit(203, fmt.Sprintf("Failed to render template: %v", err))
+			}
+		}
+		for _, stage := range []string{"post_apply", "pre_commit"} {
+			hooks.commands[stage], err = command.Hooks.RenderCommands(stage, templateValues)
+			if err != nil {
+				printErrorAndExit(203, fmt.Sprintf("Failed to render template: %v", err))
+			}
+		}
+		hooks.commands["verify"], err = command.RenderVerify(templateValues)
+		if err != nil {
+			printErrorAndExit(203, fmt.Sprintf("Failed to render template: %v", err))
+		}
+		if command.GitOptions.Tag {
+			tagName, err = command.GitOptions.BuildTagName(templateValues)
+			if err != nil {
+				printErrorAndExit(203, fmt.Sprintf("Failed to render template: %v", err))
+			}
+			tagMessage, err = command.GitOptions.BuildTagMessage(templateValues)
+			if err != nil {
+				printErrorAndExit(203, fmt.Sprintf("Failed to render template: %v", err))
+			}
+		}
+	}
+
+	// Decision: Save plan?
+	if repver.Subcommand == "plan" {
+		if repver.PlanOut == "" {
+			printErrorAndExit(118, "No plan file specified", "Usage: repver plan --command=<command_name> --out=<plan file>")
+		}
+
+		// Process: Write plan
+		saved := repver.NewSavedPlan(command, argumentValues, extractedGroups, executionPlans, branchName, commitMessage, tagName, tagMessage)
+		saved.Hooks = repver.SavedHooks{
+			PrePlan:   hooks.commands["pre_plan"],
+			PostApply: hooks.commands["post_apply"],
+			PreCommit: hooks.commands["pre_commit"],
+			Verify:    hooks.commands["verify"],
+			Timeout:   command.Hooks.Timeout,
+		}
+		if err := saved.Write(repver.PlanOut); err != nil {
+			printErrorAndExit(119, fmt.Sprintf("Failed to write plan: %v", err))
+		}
+		for _, plan := range executionPlans {
+			plan.PrintChanges()
+		}
+		fmt.Println(color.Greenf("Saved plan to %s; run 'repver apply %s' to apply it.", repver.PlanOut, repver.PlanOut))
+		if !anyFileModified {
+			fmt.Println(color.Green("No updates needed; target files already match the requested values."))
+			writeReport(statusNoop, 0, "")
+			return
+		}
+		writeReport(statusSuccess, 0, "")
+		return
+	}
 
 	if !anyFileModified {
+		// Process: Restore files changed by pre_plan hooks so a no-op leaves the repository untouched
+		stepCtx, cancel := stepContext(context.Background(), &command.GitOptions, "restore_files")
+		err := hooks.restore(stepCtx, client, "")
+		cancel()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, color.Yellowf("Warning: failed to restore files changed by hooks: %v", err))
+		}
 		fmt.Println(color.Green("No updates needed; target files already match the requested values."))
+		writeReport(statusNoop, 0, "")
 		return
 	}
 
+	replan := func(target repver.RepverTarget) (*repver.ExecutionPlan, error) {
+		return target.Plan(argumentValues, transformValues)
+	}
+	runPlans(client, command.Targets, executionPlans, &command.GitOptions, runValues{
+		branchName:    branchName,
+		commitMessage: commitMessage,
+		tagName:       tagName,
+		tagMessage:    tagMessage,
+	}, hooks, replan)
+}
+
+// runPrePlanHooks runs the pre_plan hooks before the targets are planned, or
+// before a saved plan is applied. When git is used, the workspace must be clean
+// beforehand, apart from ignorePaths, so that the files the hooks change can be
+// told apart and committed with the targets.
+func runPrePlanHooks(client git.Client, hooks *hookRunner, gitOptions *repver.RepverGit, ignorePaths []string) error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	var hookClient git.Client
+	if gitOptions.GitOptionsSpecified() {
+		// Decision: Git workspace clean?
+		stepCtx, cancel := stepContext(ctx, gitOptions, "query")
+		err := client.CheckGitClean(stepCtx, ignorePaths...)
+		cancel()
+		if err != nil {
+			return stepError(ctx, 107, "Git workspace not clean", err)
+		}
+		hookClient = client
+	}
+
+	// Process: Run pre_plan hooks
+	return hooks.run(ctx, "pre_plan", "", hookClient, gitOptions, ignorePaths)
+}
+
+// runValues holds the values a run renders from the git templates of the
+// command, or reads from a saved plan, along with the paths it ignores
+type runValues struct {
+	branchName    string
+	commitMessage string
+	tagName       string
+	tagMessage    string
+	// ignorePaths do not count towards a dirty workspace, such as the plan file being applied
+	ignorePaths []string
+}
+
+// runPlans executes the plans and reports the result. Ctrl-C or SIGTERM
+// cancels the run, which cleans up and exits with code 130.
+func runPlans(client git.Client, targets []repver.RepverTarget, plans []*repver.ExecutionPlan, gitOptions *repver.RepverGit, run runValues, hooks *hookRunner, replan replanFunc) {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	err := executePlans(ctx, client, targets, plans, gitOptions, run, hooks, replan)
+	stop()
+	if err != nil {
+		exitWithError(err)
+	}
+	writeReport(statusSuccess, 0, "")
+}
+
+// executePlans writes the planned changes to the targets and performs the git
+// operations with the rendered branch name, commit message and tag in run. It is
+// shared by normal runs and the apply subcommand, and expects at least one plan to
+// modify its target. Files in run.ignorePaths do not count towards a dirty workspace,
+// hooks, if not nil, run after the targets are written and before committing, and
+// replan, if not nil, plans a target again when an existing branch is reused. Each
+// git step runs with the time limit configured for it, and canceling ctx stops
+// the run. Any failure is returned as an *exitError carrying the exit code
+// after the work of the run has been cleaned up.
+func executePlans(ctx context.Context, client git.Client, targets []repver.RepverTarget, plans []*repver.ExecutionPlan, gitOptions *repver.RepverGit, run runValues, hooks *hookRunner, replan replanFunc) (err error) {
+	commitFiles := modifiedPaths(plans)
+
 	// If dry run mode is enabled, output that information only after confirming
 	// there is actual work to preview.
 	if repver.DryRun {
 		fmt.Println(color.Yellow("DRY RUN MODE ENABLED"))
 	}
 
+	// Track what the run has done so it can be undone if the run does not
+	// complete, and restore stashed changes however the run ends
+	state := &runState{client: client, workClient: client, hooks: hooks}
+	defer func() {
+		state.finish(gitOptions, err != nil)
+	}()
+
 	// Decision: Git options specified?
-	useGit := command.GitOptions.GitOptionsSpecified()
+	useGit := gitOptions.GitOptionsSpecified()
 	if useGit && !repver.DryRun {
 		// Decision: In git root?
-		isGitRoot, err := git.IsGitRoot()
+		stepCtx, cancel := stepContext(ctx, gitOptions, "query")
+		isGitRoot, err := client.IsGitRoot(stepCtx)
+		cancel()
 		if err != nil {
 			// This error isn't in the flowchart because the failure here is
-			printErrorAndExit(503, "Internal error determining git root")
+			return stepError(ctx, 503, "Internal error determining git root", err)
 		}
 		if !isGitRoot {
-			printErrorAndExit(106, "Not in git repository")
+			return newExitError(106, "Not in git repository")
 		}
 
 		// Decision: Git workspace clean?
-		err = git.CheckGitClean()
-		if err != nil {
-			printErrorAndExit(107, "Git workspace not clean")
+		stepCtx, cancel = stepContext(ctx, gitOptions, "query")
+		// Files changed by pre_plan hooks are committed with the targets
+		cleanErr := client.CheckGitClean(stepCtx, append(slices.Clone(run.ignorePaths), hooks.paths()...)...)
+		cancel()
+		dirty := errors.Is(cleanErr, git.ErrNotClean)
+		if cleanErr != nil && !(dirty && (gitOptions.DirtyTree == "stash" || gitOptions.DirtyTree == "worktree")) {
+			return stepError(ctx, 107, "Git workspace not clean", cleanErr)
+		}
+
+		// Decision: Stash local changes?
+		if dirty && gitOptions.DirtyTree == "stash" {
+			// Process: Stash local changes
+			stepCtx, cancel = stepContext(ctx, gitOptions, "stash")
+			output, err := client.StashChanges(stepCtx, "repver: local changes stashed during the run")
+			cancel()
+			if err != nil {
+				return stepError(ctx, 511, "Internal error failed to stash local changes", err)
+			}
+			state.stashed = true
+			repver.Debugln("Stashed local changes\n%s", output)
+			fmt.Println(color.Yellow("Stashed local changes; they will be restored when the run finishes"))
+			addGitStep("stash", "", true)
 		}
 	} else if useGit && repver.DryRun {
 		fmt.Println(color.Yellow("[DRYRUN] Git operations would be performed but are disabled in dry run mode"))
+		switch gitOptions.DirtyTree {
+		case "stash":
+			fmt.Println(color.Yellow("[DRYRUN] Would stash local changes during the run if the workspace is not clean"))
+		case "worktree":
+			fmt.Println(color.Yellow("[DRYRUN] Would perform the git operations in a temporary worktree"))
+		}
+	}
+
+	// Decision: Branch and tag names valid?
+	if err := checkRefNames(ctx, client, gitOptions, run.branchName, run.tagName); err != nil {
+		return err
+	}
+
+	// Decision: Tag already exists?
+	if gitOptions.Tag && gitOptions.Commit {
+		if err := checkTagAvailable(ctx, client, gitOptions, run.tagName); err != nil {
+			return err
+		}
 	}
 
 	// Execution Phase
@@ -274,146 +640,935 @@ func main() {
 	newBranchName := ""
 	if useGit && !repver.DryRun {
 		// Process: Get the current branch name
-		originalBranchName, err = git.GetCurrentBranch()
+		stepCtx, cancel := stepContext(ctx, gitOptions, "query")
+		originalBranchName, err = client.GetCurrentBranch(stepCtx)
+		cancel()
 		if err != nil {
 			// This error isn't in the flowchart because we previously checked we are in a git repo
-			printErrorAndExit(504, "Internal error could not get current branch name")
+			return stepError(ctx, 504, "Internal error could not get current branch name", err)
+		}
+		state.originalBranch = originalBranchName
+
+		// Decision: Use a worktree?
+		if gitOptions.DirtyTree == "worktree" {
+			// Process: Check out HEAD in a temporary worktree
+			if err := state.addWorktree(ctx, gitOptions); err != nil {
+				return stepError(ctx, 512, "Internal error failed to create worktree", err)
+			}
+		}
+
+		// Decision: Targets unchanged from the last commit?
+		if err := state.checkTargetsUnchanged(plans, state.stashed || state.worktree != ""); err != nil {
+			return newExitError(204, "Target file has uncommitted changes", fmt.Sprintf("%v\n\nThe plan was made from the uncommitted content of the file. Commit or discard the changes to the target files and run repver again.", err))
 		}
 
 		// Decision: Create new branch?
 		newBranchName = originalBranchName
-		if command.GitOptions.CreateBranch {
-			newBranchName = command.GitOptions.BuildBranchName(argumentValues)
+		if gitOptions.CreateBranch {
+			// Decision: Base branch specified?
+			if gitOptions.BaseBranch != "" {
+				// Process: Fetch and check the base branch
+				if err := prepareBaseBranch(ctx, client, gitOptions); err != nil {
+					return err
+				}
+			}
 
-			// Decision: Branch already exists?
-			branchExists, err := git.BranchExists(newBranchName)
+			// Process: Create new branch, or handle the existing branch
+			newBranchName, err = state.checkoutBranch(ctx, gitOptions, run.branchName)
 			if err != nil {
-				printErrorAndExit(503, "Internal error checking if branch exists")
-			}
-			if branchExists {
-				printErrorAndExit(200, fmt.Sprintf("Branch '%s' already exists", newBranchName))
+				return err
 			}
 
-			// Process: Create new branch
-			output, err := git.CreateAndSwitchBranch(newBranchName)
-			// Decision: Branch creation successful?
-			if err != nil {
-				printErrorAndExit(201, "Failed to create new branch")
+			// Decision: Existing branch reused?
+			if state.branchAction == "reuse" {
+				// Process: Plan the update again from the files on the branch
+				if err := state.replanOnBranch(targets, plans, replan); err != nil {
+					return newExitError(209, "Could not apply the plan to the existing branch", fmt.Sprintf("%v\n\nUse on_existing_branch: recreate to start the branch again from the base.", err))
+				}
+				commitFiles = modifiedPaths(plans)
+				if len(commitFiles) == 0 {
+					fmt.Println(color.Greenf("Branch '%s' already has the requested values; nothing to commit.", newBranchName))
+				}
+			} else if err := state.checkTargetsUnchanged(plans, gitOptions.BaseBranch != ""); err != nil {
+				// Decision: Targets unchanged on the base branch?
+				return newExitError(207, "Target file differs on the base branch", fmt.Sprintf("%v\n\nThe plan was made from the files in the current checkout. Run repver from '%s', or bring the current branch up to date with it.", err, gitOptions.BaseBranch))
 			}
-			repver.Debugln("Created and switched to new branch\n%s", output)
 		}
-	} else if useGit && repver.DryRun && command.GitOptions.CreateBranch {
+	} else if useGit && repver.DryRun && gitOptions.CreateBranch {
 		// Process: Get the current branch name
-		originalBranchName, err = git.GetCurrentBranch()
+		stepCtx, cancel := stepContext(ctx, gitOptions, "query")
+		originalBranchName, err = client.GetCurrentBranch(stepCtx)
+		cancel()
 		if err != nil {
 			// This error isn't in the flowchart because we previously checked we are in a git repo
-			printErrorAndExit(504, "Internal error could not get current branch name")
+			return stepError(ctx, 504, "Internal error could not get current branch name", err)
 		}
 
 		// In dry run mode, just show what branch would be created
-		newBranchName = command.GitOptions.BuildBranchName(argumentValues)
-		fmt.Println(color.Yellowf("[DRYRUN] Would create and switch to branch: %s", newBranchName))
+		if gitOptions.BaseBranch != "" && gitOptions.Fetch {
+			remoteBranch, _ := gitOptions.BaseRemoteBranch()
+			fmt.Println(color.Yellowf("[DRYRUN] Would fetch '%s' from remote '%s'", remoteBranch, gitOptions.RemoteName()))
+			addGitStep("fetch", gitOptions.RemoteName()+"/"+remoteBranch, false)
+		}
+		newBranchName, err = state.previewBranch(ctx, gitOptions, run.branchName)
+		if err != nil {
+			return err
+		}
 	}
+	report.Git.OriginalBranch = originalBranchName
+	report.Git.Branch = newBranchName
+
+	for i, target := range targets {
+		if ctx.Err() != nil {
+			return newExitError(130, "Interrupted").causedBy(ctx.Err())
+		}
 
-	for i, target := range command.Targets {
 		// Process: Execute the previously planned update to target
-		_, err := target.ExecutePlan(executionPlans[i])
+		target.Path = state.path(target.Path)
+		_, err := target.ExecutePlan(plans[i])
 
 		// Decision: Execution successful?
 		if err != nil {
-			printErrorAndExit(202, "Failed to execute command on target")
+			return newExitError(202, "Failed to execute command on target").causedBy(err)
+		}
+		if plans[i].Modified && !repver.DryRun {
+			state.written = append(state.written, plans[i])
+		}
+	}
+
+	// Decision: post_apply hooks?
+	// Changes to the targets and ignored paths are expected; any other file a
+	// hook changes is committed along with them
+	knownPaths := append(slices.Clone(commitFiles), run.ignorePaths...)
+	var hookClient git.Client
+	if useGit {
+		hookClient = state.workClient
+	}
+	if repver.DryRun {
+		hooks.preview("post_apply")
+	} else if err := hooks.run(ctx, "post_apply", state.worktree, hookClient, gitOptions, knownPaths); err != nil {
+		return err
+	}
+
+	// Decision: pre_commit hooks?
+	if gitOptions.Commit && !repver.DryRun && len(commitFiles) > 0 {
+		if err := hooks.run(ctx, "pre_commit", state.worktree, state.workClient, gitOptions, knownPaths); err != nil {
+			return err
+		}
+	}
+
+	// Decision: Verify changes?
+	// Verification runs on the files as they will be committed, so a failure
+	// restores them before anything is committed or pushed
+	if repver.DryRun {
+		if gitOptions.Commit {
+			hooks.preview("pre_commit")
+		}
+		if hooks.has("verify") {
+			for _, command := range hooks.commands["verify"] {
+				fmt.Println(color.Yellowf("[DRYRUN] Would run verify: %s", command))
+			}
+		}
+	} else if len(state.written) > 0 {
+		if err := hooks.verify(ctx, state.worktree); err != nil {
+			return err
 		}
 	}
 
 	// Decision: Commit changes to git?
-	if !anyFileModified {
-		repver.Debugln("No files modified, skipping commit")
-	} else if command.GitOptions.Commit && !repver.DryRun {
-		// Process: Construct commit message
-		commitMessage := command.GitOptions.BuildCommitMessage(argumentValues)
+	if gitOptions.Commit && !repver.DryRun && len(commitFiles) > 0 {
+		commitFiles = append(commitFiles, hooks.paths()...)
 
 		// Process: Commit changes to git
-		output, err := git.AddAndCommitFiles(commitFiles, commitMessage)
+		stepCtx, cancel := stepContext(ctx, gitOptions, "commit")
+		output, err := state.workClient.AddAndCommitFiles(stepCtx, commitFiles, run.commitMessage, commitOptions(gitOptions))
+		cancel()
 		if err != nil {
 			// This error isn't in the flowchart because we previously checked we are in a git repo
-			printErrorAndExit(505, "Internal error could not add and commit files")
+			return stepError(ctx, 505, "Internal error could not add and commit files", err)
 		}
+		state.committed = true
 		repver.Debugln("Changes committed successfully\n%s", output)
+		reportCommit(gitOptions, run.commitMessage)
+		stepCtx, cancel = stepContext(ctx, gitOptions, "query")
+		report.Git.CommitSHA, _ = state.workClient.GetHeadSHA(stepCtx)
+		cancel()
+		addGitStep("commit", report.Git.CommitSHA, true)
+
+		// Decision: Create tag?
+		if gitOptions.Tag {
+			// Process: Create annotated tag
+			stepCtx, cancel := stepContext(ctx, gitOptions, "tag")
+			output, err := state.workClient.CreateTag(stepCtx, run.tagName, git.TagOptions{
+				Message:    run.tagMessage,
+				Sign:       gitOptions.TagSign != "",
+				SignFormat: repver.SignFormat(gitOptions.TagSign),
+			})
+			cancel()
+			if err != nil {
+				return stepError(ctx, 515, "Internal error failed to create tag", err)
+			}
+			state.tag = run.tagName
+			repver.Debugln("Created tag %s\n%s", run.tagName, output)
+			report.Git.Tag = run.tagName
+			addGitStep("tag", run.tagName, true)
+		}
 
 		// Decision: Push changes to remote?
-		if command.GitOptions.Push && newBranchName != "" {
-			remote := command.GitOptions.Remote
-			if remote == "" {
-				remote = "origin"
-			}
+		if gitOptions.Push && newBranchName != "" {
+			remote := gitOptions.RemoteName()
 
 			// Process: Push changes to remote
-			output, err = git.PushChanges(remote, newBranchName)
+			stepCtx, cancel := stepContext(ctx, gitOptions, "push")
+			output, err = state.workClient.PushChanges(stepCtx, remote, newBranchName, state.branchAction == "recreate")
+			cancel()
 			if err != nil {
 				// This error isn't in the flowchart because we previously checked we are in a git repo
-				printErrorAndExit(506, "Internal error failed to push changes")
+				return stepError(ctx, 506, "Internal error failed to push changes", err)
 			}
 			repver.Debugln("Changes pushed successfully\n%s", output)
+			report.Git.PushRemote = remote
+			report.Git.PushBranch = newBranchName
+			addGitStep("push", remote+"/"+newBranchName, true)
+
+			// Decision: Pull request already open for an existing branch?
+			openPullRequestURL := ""
+			if gitOptions.PullRequest == "GITHUB_CLI" && (state.branchAction == "reuse" || state.branchAction == "recreate") {
+				stepCtx, cancel := stepContext(ctx, gitOptions, "pull_request")
+				openPullRequestURL, err = state.workClient.GetGitHubPullRequestURL(stepCtx, newBranchName)
+				cancel()
+				if err != nil {
+					return stepError(ctx, 508, "Failed to find GitHub pull request", err)
+				}
+				if openPullRequestURL != "" {
+					fmt.Println(color.Greenf("Updated pull request %s", openPullRequestURL))
+					report.Git.PullRequestURL = openPullRequestURL
+				}
+			}
 
 			// Decision: Create pull request?
-			if command.GitOptions.PullRequest == "GITHUB_CLI" {
-				output, err = git.CreateGitHubPullRequest()
+			if gitOptions.PullRequest == "GITHUB_CLI" && openPullRequestURL == "" {
+				stepCtx, cancel := stepContext(ctx, gitOptions, "pull_request")
+				base, _ := gitOptions.BaseRemoteBranch()
+				output, err = state.workClient.CreateGitHubPullRequest(stepCtx, base)
+				cancel()
 				if err != nil {
-					printErrorAndExit(508, "Failed to create GitHub pull request")
+					return stepError(ctx, 508, "Failed to create GitHub pull request", err)
 				}
 				repver.Debugln("Created GitHub pull request\n%s", output)
+				report.Git.PullRequestURL = git.ParsePullRequestURL(output)
+				addGitStep("pull_request", report.Git.PullRequestURL, true)
 			}
 		}
-	} else if command.GitOptions.Commit && repver.DryRun {
+
+		// Decision: Push tag to remote?
+		if gitOptions.Tag && gitOptions.PushTags {
+			remote := gitOptions.RemoteName()
+
+			// Process: Push tag to remote
+			stepCtx, cancel := stepContext(ctx, gitOptions, "push")
+			output, err = state.workClient.PushTag(stepCtx, remote, run.tagName)
+			cancel()
+			if err != nil {
+				return stepError(ctx, 516, "Internal error failed to push tag", err)
+			}
+			state.tag = ""
+			repver.Debugln("Tag pushed successfully\n%s", output)
+			addGitStep("push_tag", remote+"/"+run.tagName, true)
+		}
+	} else if gitOptions.Commit && repver.DryRun {
 		// In dry run mode, just show what would be committed
-		commitMessage := command.GitOptions.BuildCommitMessage(argumentValues)
-		fmt.Println(color.Yellowf("[DRYRUN] Would commit changes with message: \"%s\"", commitMessage))
+		if subject, body, found := strings.Cut(run.commitMessage, "\n"); found {
+			fmt.Println(color.Yellowf("[DRYRUN] Would commit changes with message: \"%s\"", subject))
+			for _, line := range strings.Split(body, "\n") {
+				fmt.Printf("  %s\n", line)
+			}
+		} else {
+			fmt.Println(color.Yellowf("[DRYRUN] Would commit changes with message: \"%s\"", run.commitMessage))
+		}
+		if gitOptions.Author != "" {
+			fmt.Println(color.Yellowf("[DRYRUN] Would set the commit author to '%s'", gitOptions.Author))
+		}
+		if gitOptions.Sign == "true" {
+			fmt.Println(color.Yellow("[DRYRUN] Would sign the commit"))
+		} else if gitOptions.Sign != "" {
+			fmt.Println(color.Yellowf("[DRYRUN] Would sign the commit using %s", gitOptions.Sign))
+		}
+		if gitOptions.Signoff {
+			fmt.Println(color.Yellow("[DRYRUN] Would add a Signed-off-by trailer for the committer"))
+		}
+		reportCommit(gitOptions, run.commitMessage)
+		addGitStep("commit", "", false)
+		if gitOptions.Tag {
+			if gitOptions.TagSign != "" {
+				fmt.Println(color.Yellowf("[DRYRUN] Would create signed tag '%s' with message: \"%s\"", run.tagName, run.tagMessage))
+			} else {
+				fmt.Println(color.Yellowf("[DRYRUN] Would create tag '%s' with message: \"%s\"", run.tagName, run.tagMessage))
+			}
+			report.Git.Tag = run.tagName
+			addGitStep("tag", run.tagName, false)
+		}
 		fmt.Println(color.Yellow("[DRYRUN] Files that would be added to the commit:"))
 		for _, file := range commitFiles {
 			fmt.Printf("  - %s\n", file)
 		}
+		if hooks.has("pre_plan") || hooks.has("post_apply") || hooks.has("pre_commit") {
+			fmt.Println("  - any other files changed by the hooks")
+		}
 
-		if command.GitOptions.Push {
-			remote := command.GitOptions.Remote
-			if remote == "" {
-				remote = "origin"
+		if gitOptions.Push {
+			remote := gitOptions.RemoteName()
+			if state.branchAction == "recreate" {
+				fmt.Println(color.Yellowf("[DRYRUN] Would push changes with --force-with-lease to remote '%s' branch '%s'", remote, newBranchName))
+			} else {
+				fmt.Println(color.Yellowf("[DRYRUN] Would push changes to remote '%s' branch '%s'", remote, newBranchName))
 			}
-			fmt.Println(color.Yellowf("[DRYRUN] Would push changes to remote '%s' branch '%s'", remote, newBranchName))
+			report.Git.PushRemote = remote
+			report.Git.PushBranch = newBranchName
+			addGitStep("push", remote+"/"+newBranchName, false)
 		}
 
-		if command.GitOptions.PullRequest == "GITHUB_CLI" {
-			fmt.Println(color.Yellow("[DRYRUN] Would create GitHub pull request"))
+		if gitOptions.PullRequest == "GITHUB_CLI" {
+			if base, _ := gitOptions.BaseRemoteBranch(); base != "" {
+				fmt.Println(color.Yellowf("[DRYRUN] Would create GitHub pull request against '%s'", base))
+			} else {
+				fmt.Println(color.Yellow("[DRYRUN] Would create GitHub pull request"))
+			}
+			addGitStep("pull_request", "", false)
+		}
+
+		if gitOptions.Tag && gitOptions.PushTags {
+			remote := gitOptions.RemoteName()
+			fmt.Println(color.Yellowf("[DRYRUN] Would push tag '%s' to remote '%s'", run.tagName, remote))
+			addGitStep("push_tag", remote+"/"+run.tagName, false)
 		}
 	}
 
-	// Decision: Return to original branch?
-	if command.GitOptions.ReturnToOriginalBranch && !repver.DryRun && anyFileModified {
+	// Decision: Working in a worktree?
+	if state.worktree != "" {
+		// Process: Remove the worktree; the original checkout was never changed
+		stepCtx, cancel := stepContext(ctx, gitOptions, "worktree")
+		err := state.removeWorktree(stepCtx)
+		cancel()
+		if err != nil {
+			return stepError(ctx, 513, "Internal error failed to remove worktree", err)
+		}
+	} else if gitOptions.ReturnToOriginalBranch && !repver.DryRun {
+		// Decision: Return to original branch?
 		// Process: Switch back to original branch
-		output, err := git.SwitchToBranch(originalBranchName)
+		stepCtx, cancel := stepContext(ctx, gitOptions, "switch_branch")
+		output, err := client.SwitchToBranch(stepCtx, originalBranchName)
+		cancel()
 		if err != nil {
 			// This error isn't in the flowchart because we previously checked we are in a git repo
-			printErrorAndExit(507, "Internal error failed to switch back to original branch")
+			return stepError(ctx, 507, "Internal error failed to switch back to original branch", err)
 		}
 		repver.Debugln("Returned to original branch\n%s", output)
+		addGitStep("switch_branch", originalBranchName, true)
+	} else if gitOptions.ReturnToOriginalBranch && repver.DryRun {
+		if gitOptions.DirtyTree == "worktree" {
+			fmt.Println(color.Yellow("[DRYRUN] Would remove the temporary worktree"))
+		} else {
+			fmt.Println(color.Yellowf("[DRYRUN] Would switch back to original branch '%s'", originalBranchName))
+			addGitStep("switch_branch", originalBranchName, false)
+		}
+	}
+
+	// Decision: Delete new branch?
+	if gitOptions.ReturnToOriginalBranch && gitOptions.DeleteBranch && gitOptions.CreateBranch && !repver.DryRun {
+		// Process: Delete new branch
+		stepCtx, cancel := stepContext(ctx, gitOptions, "delete_branch")
+		output, err := client.DeleteLocalBranch(stepCtx, newBranchName)
+		cancel()
+		if err != nil {
+			// This error isn't in the flowchart because we previously checked we are in a git repo
+			return stepError(ctx, 509, "Internal error failed to delete new branch", err)
+		}
+		repver.Debugln("Deleted branch\n%s", output)
+		addGitStep("delete_branch", newBranchName, true)
+	} else if gitOptions.ReturnToOriginalBranch && gitOptions.DeleteBranch && gitOptions.CreateBranch && repver.DryRun {
+		fmt.Println(color.Yellowf("[DRYRUN] Would delete branch '%s'", newBranchName))
+		addGitStep("delete_branch", newBranchName, false)
+	}
+
+	return nil
+}
+
+// stepContext returns the context for a git step, limited to the time
+// configured for the step
+func stepContext(ctx context.Context, gitOptions *repver.RepverGit, step string) (context.Context, context.CancelFunc) {
+	timeout := gitOptions.StepTimeout(step)
+	if timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+// stepError returns the exit error for a failed git step. A step that failed
+// because the run was interrupted is reported as interrupted.
+func stepError(ctx context.Context, code int, message string, err error) *exitError {
+	if ctx.Err() != nil {
+		return newExitError(130, "Interrupted").causedBy(err)
+	}
+	return newExitError(code, message).causedBy(err)
+}
+
+// commitOptions returns the options for the commit step
+func commitOptions(gitOptions *repver.RepverGit) git.CommitOptions {
+	return git.CommitOptions{
+		Sign:       gitOptions.Sign != "",
+		SignFormat: repver.SignFormat(gitOptions.Sign),
+		Author:     gitOptions.Author,
+		Signoff:    gitOptions.Signoff,
+	}
+}
+
+// reportCommit records the commit message and options in the run report
+func reportCommit(gitOptions *repver.RepverGit, commitMessage string) {
+	report.Git.CommitMessage = commitMessage
+	report.Git.CommitAuthor = gitOptions.Author
+	report.Git.CommitSign = gitOptions.Sign
+	report.Git.CommitSignoff = gitOptions.Signoff
+}
+
+// checkRefNames checks that the rendered branch and tag names are valid git
+// ref names. Templates can produce invalid names from the values they use,
+// such as previous values that differ between targets and are listed with ", ".
+func checkRefNames(ctx context.Context, client git.Client, gitOptions *repver.RepverGit, branchName string, tagName string) error {
+	if gitOptions.CreateBranch {
+		if err := checkRefName(ctx, client, gitOptions, "branch", "refs/heads/", branchName); err != nil {
+			return err
+		}
+	}
+	if gitOptions.Tag && gitOptions.Commit {
+		if err := checkRefName(ctx, client, gitOptions, "tag", "refs/tags/", tagName); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkRefName checks that name is a valid ref name under prefix
+func checkRefName(ctx context.Context, client git.Client, gitOptions *repver.RepverGit, kind string, prefix string, name string) error {
+	stepCtx, cancel := stepContext(ctx, gitOptions, "query")
+	valid, err := client.ValidRefName(stepCtx, prefix+name)
+	cancel()
+	if err != nil {
+		return stepError(ctx, 519, "Internal error failed to check ref name", err)
+	}
+	if !valid {
+		return newExitError(213, fmt.Sprintf("Invalid %s name '%s'", kind, name), "The rendered name is not a valid git ref name. Check the template and the values it uses; previous values that differ between targets are listed separated by \", \".")
+	}
+	return nil
+}
+
+// checkTagAvailable checks that the tag does not exist locally or, if tags
+// are pushed, on the remote, before any changes are made
+func checkTagAvailable(ctx context.Context, client git.Client, gitOptions *repver.RepverGit, tagName string) error {
+	stepCtx, cancel := stepContext(ctx, gitOptions, "query")
+	exists, err := client.RefExists(stepCtx, "refs/tags/"+tagName)
+	cancel()
+	if err != nil {
+		return stepError(ctx, 517, "Internal error failed to check for existing tag", err)
+	}
+	if exists {
+		return newExitError(210, fmt.Sprintf("Tag '%s' already exists", tagName))
+	}
 
-		// Decision: Delete new branch?
-		if command.GitOptions.DeleteBranch && command.GitOptions.CreateBranch {
+	if !gitOptions.PushTags {
+		return nil
+	}
+	remote := gitOptions.RemoteName()
+	stepCtx, cancel = stepContext(ctx, gitOptions, "tag")
+	exists, err = client.RemoteTagExists(stepCtx, remote, tagName)
+	cancel()
+	if err != nil {
+		return stepError(ctx, 517, "Internal error failed to check for existing tag", err)
+	}
+	if exists {
+		return newExitError(210, fmt.Sprintf("Tag '%s' already exists on remote '%s'", tagName, remote))
+	}
+	return nil
+}
 
-			// Process: Delete new branch
-			output, err = git.DeleteLocalBranch(newBranchName)
+// prepareBaseBranch fetches the base branch if configured and checks that it
+// exists. A local base branch must not have diverged from its remote-tracking
+// branch; being ahead or behind it is only reported as a warning.
+func prepareBaseBranch(ctx context.Context, client git.Client, gitOptions *repver.RepverGit) error {
+	remote := gitOptions.RemoteName()
+	remoteBranch, isRemote := gitOptions.BaseRemoteBranch()
+
+	// Decision: Fetch base branch?
+	if gitOptions.Fetch {
+		stepCtx, cancel := stepContext(ctx, gitOptions, "fetch")
+		output, err := client.Fetch(stepCtx, remote, remoteBranch)
+		cancel()
+		if err != nil {
+			return stepError(ctx, 514, "Internal error failed to fetch base branch", err)
+		}
+		repver.Debugln("Fetched base branch\n%s", output)
+		addGitStep("fetch", remote+"/"+remoteBranch, true)
+	}
+
+	// Decision: Base branch exists?
+	stepCtx, cancel := stepContext(ctx, gitOptions, "query")
+	exists, err := client.RefExists(stepCtx, gitOptions.BaseBranch)
+	cancel()
+	if err != nil {
+		return stepError(ctx, 503, "Internal error checking if base branch exists", err)
+	}
+	if !exists {
+		return newExitError(205, fmt.Sprintf("Base branch '%s' not found", gitOptions.BaseBranch))
+	}
+	if isRemote {
+		return nil
+	}
+
+	// Decision: Base branch diverged from the remote?
+	upstream := remote + "/" + remoteBranch
+	stepCtx, cancel = stepContext(ctx, gitOptions, "query")
+	upstreamExists, err := client.RefExists(stepCtx, upstream)
+	cancel()
+	if err != nil {
+		return stepError(ctx, 503, "Internal error checking if base branch exists", err)
+	}
+	if !upstreamExists {
+		repver.Debugln("No remote-tracking branch '%s', skipping the divergence check", upstream)
+		return nil
+	}
+
+	stepCtx, cancel = stepContext(ctx, gitOptions, "query")
+	ahead, behind, err := client.CountDivergence(stepCtx, gitOptions.BaseBranch, upstream)
+	cancel()
+	if err != nil {
+		return stepError(ctx, 503, "Internal error comparing base branch with the remote", err)
+	}
+	if ahead > 0 && behind > 0 {
+		return newExitError(206, fmt.Sprintf("Base branch '%s' has diverged from '%s'", gitOptions.BaseBranch, upstream),
+			fmt.Sprintf("'%s' has %d commit(s) that are not on '%s', which has %d commit(s) that are not on '%s'.\nReconcile the branches, or set base_branch to '%s' to branch from the remote.", gitOptions.BaseBranch, ahead, upstream, behind, gitOptions.BaseBranch, upstream))
+	}
+	if behind > 0 {
+		fmt.Println(color.Yellowf("Warning: base branch '%s' is %d commit(s) behind '%s'", gitOptions.BaseBranch, behind, upstream))
+	}
+	if ahead > 0 {
+		fmt.Println(color.Yellowf("Warning: base branch '%s' has %d commit(s) that are not on '%s'", gitOptions.BaseBranch, ahead, upstream))
+	}
+	return nil
+}
+
+// replanFunc plans the update of a target again from its current content
+type replanFunc func(target repver.RepverTarget) (*repver.ExecutionPlan, error)
+
+// maxBranchSuffix is the highest number appended to the branch name by
+// on_existing_branch: suffix
+const maxBranchSuffix = 100
+
+// modifiedPaths returns the paths of the targets the plans modify
+func modifiedPaths(plans []*repver.ExecutionPlan) []string {
+	paths := []string{}
+	for _, plan := range plans {
+		if plan.Modified {
+			paths = append(paths, plan.Path)
+		}
+	}
+	return paths
+}
+
+// checkoutBranch switches to the branch for the run, creating it from the base
+// branch or HEAD. If the branch already exists, on_existing_branch decides
+// whether to fail, switch to it, reset it to the base, or create a branch with
+// a numbered suffix instead. It returns the name of the branch switched to.
+func (s *runState) checkoutBranch(ctx context.Context, gitOptions *repver.RepverGit, branchName string) (string, error) {
+	// Decision: Branch already exists?
+	exists, err := s.branchTaken(ctx, gitOptions, branchName, false)
+	if err != nil {
+		return "", err
+	}
+
+	action := "create"
+	if exists {
+		switch gitOptions.OnExistingBranch {
+		case "reuse", "recreate":
+			action = gitOptions.OnExistingBranch
+		case "suffix":
+			branchName, err = s.freeBranchName(ctx, gitOptions, branchName)
 			if err != nil {
-				// This error isn't in the flowchart because we previously checked we are in a git repo
-				printErrorAndExit(509, "Internal error failed to delete new branch")
+				return "", err
+			}
+		default:
+			return "", newExitError(200, fmt.Sprintf("Branch '%s' already exists", branchName))
+		}
+	}
+
+	var output string
+	switch action {
+	case "reuse":
+		// Process: Switch to the existing branch
+		stepCtx, cancel := stepContext(ctx, gitOptions, "switch_branch")
+		output, err = s.workClient.SwitchToBranch(stepCtx, branchName)
+		cancel()
+		if err != nil {
+			return "", stepError(ctx, 208, "Failed to switch to existing branch", err)
+		}
+		repver.Debugln("Switched to existing branch\n%s", output)
+	case "recreate":
+		// Process: Reset the existing branch to the base
+		stepCtx, cancel := stepContext(ctx, gitOptions, "query")
+		s.previousSHA, err = s.client.ResolveRef(stepCtx, branchName)
+		cancel()
+		if err != nil {
+			return "", stepError(ctx, 503, "Internal error checking if branch exists", err)
+		}
+		stepCtx, cancel = stepContext(ctx, gitOptions, "create_branch")
+		output, err = s.workClient.ResetAndSwitchBranch(stepCtx, branchName, gitOptions.BaseBranch)
+		cancel()
+		if err != nil {
+			return "", stepError(ctx, 201, "Failed to create new branch", err)
+		}
+		repver.Debugln("Reset and switched to existing branch\n%s", output)
+	default:
+		// Process: Create new branch
+		stepCtx, cancel := stepContext(ctx, gitOptions, "create_branch")
+		output, err = s.workClient.CreateAndSwitchBranch(stepCtx, branchName, gitOptions.BaseBranch)
+		cancel()
+		// Decision: Branch creation successful?
+		if err != nil {
+			return "", stepError(ctx, 201, "Failed to create new branch", err)
+		}
+		repver.Debugln("Created and switched to new branch\n%s", output)
+	}
+
+	s.branch = branchName
+	s.branchAction = action
+	addGitStep(action+"_branch", branchName, true)
+	return branchName, nil
+}
+
+// previewBranch shows in dry run mode which branch would be switched to and
+// how, following on_existing_branch if the branch already exists. It returns
+// the name of that branch.
+func (s *runState) previewBranch(ctx context.Context, gitOptions *repver.RepverGit, branchName string) (string, error) {
+	exists, err := s.branchTaken(ctx, gitOptions, branchName, false)
+	if err != nil {
+		return "", err
+	}
+
+	from := ""
+	if gitOptions.BaseBranch != "" {
+		from = fmt.Sprintf(" (from %s)", gitOptions.BaseBranch)
+	}
+
+	action := "create"
+	if exists {
+		switch gitOptions.OnExistingBranch {
+		case "reuse":
+			action = "reuse"
+			fmt.Println(color.Yellowf("[DRYRUN] Branch '%s' already exists; would switch to it and add a commit", branchName))
+		case "recreate":
+			action = "recreate"
+			fmt.Println(color.Yellowf("[DRYRUN] Branch '%s' already exists; would reset it%s and replace it on the remote", branchName, from))
+		case "suffix":
+			existing := branchName
+			branchName, err = s.freeBranchName(ctx, gitOptions, branchName)
+			if err != nil {
+				return "", err
+			}
+			fmt.Println(color.Yellowf("[DRYRUN] Branch '%s' already exists; would create and switch to branch: %s%s", existing, branchName, from))
+		default:
+			return "", newExitError(200, fmt.Sprintf("Branch '%s' already exists", branchName))
+		}
+	} else {
+		fmt.Println(color.Yellowf("[DRYRUN] Would create and switch to branch: %s%s", branchName, from))
+	}
+
+	s.branchAction = action
+	addGitStep(action+"_branch", branchName, false)
+	return branchName, nil
+}
+
+// branchTaken checks if a local branch with the name exists, or, when
+// includeRemote is set, a branch with the name on the remote
+func (s *runState) branchTaken(ctx context.Context, gitOptions *repver.RepverGit, branchName string, includeRemote bool) (bool, error) {
+	stepCtx, cancel := stepContext(ctx, gitOptions, "query")
+	exists, err := s.client.BranchExists(stepCtx, branchName)
+	cancel()
+	if err != nil {
+		return false, stepError(ctx, 503, "Internal error checking if branch exists", err)
+	}
+	if exists || !includeRemote {
+		return exists, nil
+	}
+
+	stepCtx, cancel = stepContext(ctx, gitOptions, "query")
+	exists, err = s.client.RefExists(stepCtx, gitOptions.RemoteName()+"/"+branchName)
+	cancel()
+	if err != nil {
+		return false, stepError(ctx, 503, "Internal error checking if branch exists", err)
+	}
+	return exists, nil
+}
+
+// freeBranchName returns the branch name with the first suffix from -2 to
+// -maxBranchSuffix that is not taken locally or on the remote
+func (s *runState) freeBranchName(ctx context.Context, gitOptions *repver.RepverGit, branchName string) (string, error) {
+	for n := 2; n <= maxBranchSuffix; n++ {
+		candidate := fmt.Sprintf("%s-%d", branchName, n)
+		taken, err := s.branchTaken(ctx, gitOptions, candidate, true)
+		if err != nil {
+			return "", err
+		}
+		if !taken {
+			return candidate, nil
+		}
+	}
+	return "", newExitError(200, fmt.Sprintf("Branch '%s' already exists", branchName),
+		fmt.Sprintf("Branches '%s-2' to '%s-%d' also exist.", branchName, branchName, maxBranchSuffix))
+}
+
+// replanOnBranch plans each target again from its content on the reused
+// branch, replacing the plans in place. Without replan, as when applying a
+// saved plan, the targets must still have the content the plans were made from.
+func (s *runState) replanOnBranch(targets []repver.RepverTarget, plans []*repver.ExecutionPlan, replan replanFunc) error {
+	if replan == nil {
+		return s.checkTargetsUnchanged(plans, true)
+	}
+	for i, target := range targets {
+		path := target.Path
+		target.Path = s.path(path)
+		plan, err := replan(target)
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		plan.Path = path
+		plans[i] = plan
+	}
+	return nil
+}
+
+// runState records what a run has done so the work of a run that fails or is
+// interrupted can be undone
+type runState struct {
+	// client runs commands in the original checkout
+	client git.Client
+	// workClient runs the branch, commit and push commands, in the worktree if
+	// one is used and otherwise in the original checkout
+	workClient git.Client
+	// worktree is the path of the temporary worktree, if one was added
+	worktree string
+	// stashed is set when local changes were stashed for the run
+	stashed bool
+	// originalBranch is the branch checked out when the run started
+	originalBranch string
+	// branch is the branch the run switched to, and branchAction how it was
+	// obtained: "create", "reuse" or "recreate"
+	branch       string
+	branchAction string
+	// previousSHA is the commit a recreated branch pointed to before the run
+	previousSHA string
+	// written holds the plans whose targets were written
+	written []*repver.ExecutionPlan
+	// committed is set once the changes are committed
+	committed bool
+	// tag is the tag created by the run, until it is pushed
+	tag string
+	// hooks runs the hooks of the run and tracks the files they changed
+	hooks *hookRunner
+}
+
+// path returns where a target is written, inside the worktree if one is used
+func (s *runState) path(path string) string {
+	if s.worktree == "" {
+		return path
+	}
+	return filepath.Join(s.worktree, path)
+}
+
+// addWorktree checks out HEAD in a new temporary worktree and runs the
+// following git operations in it
+func (s *runState) addWorktree(ctx context.Context, gitOptions *repver.RepverGit) error {
+	dir, err := os.MkdirTemp("", "repver-worktree-")
+	if err != nil {
+		return err
+	}
+
+	stepCtx, cancel := stepContext(ctx, gitOptions, "worktree")
+	output, err := s.client.AddWorktree(stepCtx, dir)
+	cancel()
+	if err != nil {
+		os.RemoveAll(dir)
+		return err
+	}
+	repver.Debugln("Added worktree at %s\n%s", dir, output)
+	addGitStep("add_worktree", dir, true)
+
+	s.worktree = dir
+	s.workClient = s.client.InDir(dir)
+	return nil
+}
+
+// removeWorktree removes the temporary worktree and its directory
+func (s *runState) removeWorktree(ctx context.Context) error {
+	output, err := s.client.RemoveWorktree(ctx, s.worktree)
+	if err != nil {
+		return err
+	}
+	repver.Debugln("Removed worktree at %s\n%s", s.worktree, output)
+	addGitStep("remove_worktree", s.worktree, true)
+	s.worktree = ""
+	s.workClient = s.client
+	return nil
+}
+
+// checkTargetsUnchanged verifies, when enabled, that each modified target
+// still has the content the plan was made from. It is needed whenever the
+// files checked out differ from those the plan was made from, after local
+// changes are stashed, in a worktree, or on a branch created from a base
+// branch, as writing the planned content would otherwise commit the
+// differences as well.
+func (s *runState) checkTargetsUnchanged(plans []*repver.ExecutionPlan, enabled bool) error {
+	if !enabled {
+		return nil
+	}
+	for _, plan := range plans {
+		if !plan.Modified {
+			continue
+		}
+		content, err := os.ReadFile(s.path(plan.Path))
+		if err != nil || string(content) != plan.OriginalContent {
+			return fmt.Errorf("%s differs from the content the plan was made from", plan.Path)
+		}
+	}
+	return nil
+}
+
+// finish undoes the work of a run that failed or was interrupted and then
+// restores any stashed changes. Cleanup uses its own context so it still runs
+// after the run's context is canceled, and problems are printed as warnings so
+// the original error is the one reported.
+func (s *runState) finish(gitOptions *repver.RepverGit, failed bool) {
+	if failed {
+		s.undo(gitOptions)
+	}
+
+	if s.stashed {
+		ctx, cancel := stepContext(context.Background(), gitOptions, "stash")
+		_, err := s.client.PopStash(ctx)
+		cancel()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, color.Yellowf("Warning: failed to restore stashed changes: %v\nRun 'git stash pop' to restore them.", err))
+			return
+		}
+		fmt.Println(color.Yellow("Restored stashed local changes"))
+		addGitStep("stash_pop", "", true)
+	}
+}
+
+// undo restores target files that were written but not committed, removes
+// the worktree, and switches back to the original branch if nothing was
+// committed to the branch of the run. A branch created by the run is then
+// deleted and a recreated branch is reset to where it was. A tag that was not
+// pushed is deleted so the run can be retried. Commits are never undone.
+func (s *runState) undo(gitOptions *repver.RepverGit) {
+	if s.tag != "" {
+		ctx, cancel := stepContext(context.Background(), gitOptions, "tag")
+		_, err := s.client.DeleteTag(ctx, s.tag)
+		cancel()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, color.Yellowf("Warning: failed to delete tag '%s': %v", s.tag, err))
+		} else {
+			fmt.Fprintln(os.Stderr, color.Yellowf("Deleted tag '%s' created by the run", s.tag))
+			addGitStep("delete_tag", s.tag, true)
+		}
+	}
+
+	if s.committed {
+		s.written = nil
+		s.branch = ""
+		s.hooks = nil
+	}
+
+	usedWorktree := s.worktree != ""
+	if usedWorktree {
+		ctx, cancel := stepContext(context.Background(), gitOptions, "worktree")
+		err := s.removeWorktree(ctx)
+		cancel()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, color.Yellowf("Warning: failed to remove worktree '%s': %v", s.worktree, err))
+		}
+	} else if len(s.written) > 0 {
+		paths := make([]string, 0, len(s.written))
+		for _, plan := range s.written {
+			paths = append(paths, plan.Path)
+		}
+
+		// In a git run the files are restored from HEAD, which also unstages them
+		var err error
+		if s.originalBranch != "" {
+			ctx, cancel := stepContext(context.Background(), gitOptions, "restore_files")
+			_, err = s.client.RestoreFiles(ctx, paths)
+			cancel()
+		} else {
+			for _, plan := range s.written {
+				if writeErr := os.WriteFile(plan.Path, []byte(plan.OriginalContent), 0644); writeErr != nil && err == nil {
+					err = writeErr
+				}
 			}
-			repver.Debugln("Deleted branch\n%s", output)
 		}
-	} else if command.GitOptions.ReturnToOriginalBranch && repver.DryRun {
-		fmt.Println(color.Yellowf("[DRYRUN] Would switch back to original branch '%s'", originalBranchName))
+		if err != nil {
+			fmt.Fprintln(os.Stderr, color.Yellowf("Warning: failed to restore target files: %v", err))
+		} else {
+			fmt.Fprintln(os.Stderr, color.Yellowf("Restored target files: %s", strings.Join(paths, ", ")))
+			addGitStep("restore_files", strings.Join(paths, " "), true)
+		}
+	}
 
-		if command.GitOptions.DeleteBranch && command.GitOptions.CreateBranch {
-			fmt.Println(color.Yellowf("[DRYRUN] Would delete branch '%s'", newBranchName))
+	// Files changed by hooks are discarded with the worktree when one is used
+	if !usedWorktree && s.originalBranch != "" {
+		ctx, cancel := stepContext(context.Background(), gitOptions, "restore_files")
+		err := s.hooks.restore(ctx, s.client, "")
+		cancel()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, color.Yellowf("Warning: failed to restore files changed by hooks: %v", err))
 		}
 	}
+
+	if s.branch == "" {
+		return
+	}
+
+	if !usedWorktree {
+		ctx, cancel := stepContext(context.Background(), gitOptions, "switch_branch")
+		_, err := s.client.SwitchToBranch(ctx, s.originalBranch)
+		cancel()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, color.Yellowf("Warning: failed to switch back to branch '%s': %v", s.originalBranch, err))
+			return
+		}
+		addGitStep("switch_branch", s.originalBranch, true)
+	}
+
+	switch s.branchAction {
+	case "create":
+		ctx, cancel := stepContext(context.Background(), gitOptions, "delete_branch")
+		_, err := s.client.DeleteLocalBranch(ctx, s.branch)
+		cancel()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, color.Yellowf("Warning: failed to delete branch '%s': %v", s.branch, err))
+			return
+		}
+		fmt.Fprintln(os.Stderr, color.Yellowf("Deleted branch '%s' created by the run", s.branch))
+		addGitStep("delete_branch", s.branch, true)
+	case "recreate":
+		ctx, cancel := stepContext(context.Background(), gitOptions, "create_branch")
+		_, err := s.client.ResetBranch(ctx, s.branch, s.previousSHA)
+		cancel()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, color.Yellowf("Warning: failed to reset branch '%s' to %s: %v", s.branch, s.previousSHA, err))
+			return
+		}
+		fmt.Fprintln(os.Stderr, color.Yellowf("Reset branch '%s' to %s, where it was before the run", s.branch, s.previousSHA))
+		addGitStep("reset_branch", s.branch+"@"+s.previousSHA, true)
+	}
 }
 
 // generateHelpMessage creates a formatted help message showing all available commands
@@ -422,7 +1577,11 @@ func generateHelpMessage(config *repver.RepverConfig) string {
 	var help strings.Builder
 
 	help.WriteString("USAGE:\n")
-	help.WriteString("  repver --command=<command_name> [--param-<n>=<value> ...] [OPTIONS]\n\n")
+	help.WriteString("  repver --command=<command_name> [--param-<n>=<value> ...] [OPTIONS]\n")
+	for _, subcommand := range repver.Subcommands {
+		help.WriteString(fmt.Sprintf("  repver %s %s\n", subcommand.Name, subcommand.Usage))
+	}
+	help.WriteString("\n")
 
 	help.WriteString("AVAILABLE COMMANDS:\n")
 
@@ -461,6 +1620,9 @@ func generateHelpMessage(config *repver.RepverConfig) string {
 		// Format command name with padding
 		padding := strings.Repeat(" ", maxNameLen-len(name)+2)
 		help.WriteString(fmt.Sprintf("  %s%s", name, padding))
+		if cmd.Description != "" {
+			help.WriteString(cmd.Description + "\n    ")
+		}
 
 		// Include example usage
 		if len(params) > 0 {
@@ -480,21 +1642,439 @@ func generateHelpMessage(config *repver.RepverConfig) string {
 	}
 
 	help.WriteString("OPTIONS:\n")
-	help.WriteString("  --debug      Enable debug output\n")
-	help.WriteString("  --dry-run    Show what would be changed without modifying files or performing git operations\n")
-	help.WriteString("  --no-color   Disable colored output (also respects NO_COLOR environment variable)\n")
+	help.WriteString("  --debug            Enable debug output\n")
+	help.WriteString("  --dry-run          Show what would be changed without modifying files or performing git operations\n")
+	help.WriteString("  --no-color         Disable colored output (also respects NO_COLOR environment variable)\n")
+	help.WriteString("  --allow-downgrade  Apply changes that lower a value protected by a monotonic policy\n")
+	help.WriteString("  --no-input         Never prompt for missing parameters\n")
+	help.WriteString("  --diff             Show file changes as unified diffs (context lines set by --diff-context, default 3)\n")
+	help.WriteString("  --patch-out=<file> Write the changes to a patch file without modifying files or running git\n")
+	help.WriteString("  --output=json      Print a JSON report of the run to stdout and all other output to stderr\n")
+	help.WriteString("  --help             Show this help, or the help for a command with --command=<command_name>\n")
+
+	return help.String()
+}
+
+// collectBuiltinValues gathers the built-in template values for a command.
+// Git and repository values are left empty when they cannot be determined,
+// for example when not running inside a Git repository.
+func collectBuiltinValues(client git.Client, command *repver.RepverCommand) map[string]string {
+	values := repver.BuiltinValues(command.Name, time.Now())
+
+	ctx, cancel := stepContext(context.Background(), &command.GitOptions, "query")
+	defer cancel()
+
+	values["git.short_sha"], _ = client.GetShortSHA(ctx)
+	values["git.branch"], _ = client.GetCurrentBranch(ctx)
+	values["git.user"], _ = client.GetUserName(ctx)
+
+	remote := command.GitOptions.RemoteName()
+	values["repo.owner"] = ""
+	values["repo.name"] = ""
+	if remoteURL, err := client.GetRemoteURL(ctx, remote); err == nil {
+		if owner, name, err := git.ParseRepoName(remoteURL); err == nil {
+			values["repo.owner"] = owner
+			values["repo.name"] = name
+		}
+	} else {
+		repver.Debugln("Could not determine url for remote '%s': %v", remote, err)
+	}
+
+	return values
+}
+
+// generateCommandHelp creates a detailed help message for a single command showing
+// each param with its pattern, description and example, the target files, and
+// the git steps the command would run
+func generateCommandHelp(cmd *repver.RepverCommand) string {
+	var help strings.Builder
+
+	help.WriteString(fmt.Sprintf("COMMAND: %s\n", cmd.Name))
+	if cmd.Description != "" {
+		help.WriteString(fmt.Sprintf("  %s\n", cmd.Description))
+	}
+	help.WriteString("\n")
+
+	params, err := cmd.GetParameterNames()
+	if err != nil {
+		params = nil
+	}
+
+	help.WriteString("USAGE:\n")
+	help.WriteString(fmt.Sprintf("  repver --command=%s", cmd.Name))
+	for _, param := range params {
+		help.WriteString(fmt.Sprintf(" --param-%s=<value>", param))
+	}
+	help.WriteString(" [OPTIONS]\n\n")
+
+	help.WriteString("PARAMETERS:\n")
+	if len(params) == 0 {
+		help.WriteString("  No parameters required\n")
+	}
+	for _, name := range params {
+		help.WriteString(fmt.Sprintf("  --param-%s\n", name))
+		param := cmd.GetParam(name)
+		if param == nil {
+			help.WriteString("    Pattern:     (any value)\n")
+			continue
+		}
+		if param.Description != "" {
+			help.WriteString(fmt.Sprintf("    Description: %s\n", param.Description))
+		}
+		help.WriteString(fmt.Sprintf("    Pattern:     %s\n", param.Pattern))
+		if param.Example != "" {
+			help.WriteString(fmt.Sprintf("    Example:     %s\n", param.Example))
+		}
+	}
+	help.WriteString("\n")
+
+	help.WriteString("TARGETS:\n")
+	for _, target := range cmd.Targets {
+		help.WriteString(fmt.Sprintf("  %s\n", target.Path))
+		if target.Description != "" {
+			help.WriteString(fmt.Sprintf("    Description: %s\n", target.Description))
+		}
+		help.WriteString(fmt.Sprintf("    Pattern:     %s\n", target.Pattern))
+		if target.Transform != "" {
+			help.WriteString(fmt.Sprintf("    Transform:   %s\n", target.Transform))
+		}
+		groups := make([]string, 0, len(target.Transforms))
+		for group := range target.Transforms {
+			groups = append(groups, group)
+		}
+		sort.Strings(groups)
+		for _, group := range groups {
+			help.WriteString(fmt.Sprintf("    Transform:   %s = %s\n", group, target.Transforms[group]))
+		}
+	}
+	help.WriteString("\n")
+
+	if cmd.Hooks.Specified() {
+		help.WriteString("HOOKS:\n")
+		for _, stage := range repver.HookStages {
+			for _, command := range cmd.Hooks.Commands(stage) {
+				help.WriteString(fmt.Sprintf("  %-11s %s\n", stage+":", command))
+			}
+		}
+		help.WriteString("\n")
+	}
+
+	if len(cmd.Verify) > 0 {
+		help.WriteString("VERIFY:\n")
+		for _, command := range cmd.Verify {
+			help.WriteString(fmt.Sprintf("  %s\n", command))
+		}
+		help.WriteString("\n")
+	}
+
+	help.WriteString("GIT STEPS:\n")
+	steps := cmd.GitOptions.DescribeSteps()
+	if len(steps) == 0 {
+		help.WriteString("  No git operations configured\n")
+	}
+	for i, step := range steps {
+		help.WriteString(fmt.Sprintf("  %d. %s\n", i+1, step))
+	}
 
 	return help.String()
 }
 
+// handleHelpMode handles the help subcommand and the --help flag.
+// It prints the help for the command named by --command or the first argument
+// after the help subcommand, or the general help if no command is named.
+func handleHelpMode(config *repver.RepverConfig) {
+	name := repver.UserCommand
+	if name == "" && repver.Subcommand == "help" && len(repver.Args) > 0 {
+		name = repver.Args[0]
+	}
+
+	if name == "" {
+		fmt.Print(generateHelpMessage(config))
+		return
+	}
+
+	command, err := config.GetCommand(name)
+	if err != nil {
+		printErrorAndExit(104, "Command not found", generateHelpMessage(config))
+	}
+
+	fmt.Print(generateCommandHelp(command))
+}
+
+// exitError is an error that ends the run with a specific exit code
+type exitError struct {
+	code    int
+	message string
+	help    string
+	cause   error
+}
+
+func (e *exitError) Error() string {
+	if e.cause != nil {
+		return fmt.Sprintf("error (%d): %s: %v", e.code, e.message, e.cause)
+	}
+	return fmt.Sprintf("error (%d): %s", e.code, e.message)
+}
+
+func (e *exitError) Unwrap() error {
+	return e.cause
+}
+
+// newExitError creates an error that ends the run with the given exit code,
+// message and optional help text
+func newExitError(code int, message string, help ...string) *exitError {
+	err := &exitError{code: code, message: message}
+	if len(help) > 0 {
+		err.help = help[0]
+	}
+	return err
+}
+
+// causedBy records the underlying error so its details are shown in the error output
+func (e *exitError) causedBy(err error) *exitError {
+	e.cause = err
+	return e
+}
+
+// exitWithError prints the error and exits with its code. Details of a failed
+// git or gh command are shown below the message. Errors that do not carry an
+// exit code are reported as internal errors.
+func exitWithError(err error) {
+	var exitErr *exitError
+	if !errors.As(err, &exitErr) {
+		printErrorAndExit(500, fmt.Sprintf("Internal error: %v", err))
+	}
+
+	help := exitErr.help
+	if exitErr.cause != nil && help == "" {
+		var gitErr *git.GitError
+		if errors.As(exitErr.cause, &gitErr) {
+			report.GitError = gitErr
+			help = gitErr.Detail()
+		} else {
+			help = exitErr.cause.Error()
+		}
+	}
+	printErrorAndExit(exitErr.code, exitErr.message, help)
+}
+
 func printErrorAndExit(errNum int, errMsg string, helpMsg ...string) {
 	fmt.Fprintf(os.Stderr, "%s %s\n", color.BoldRed(fmt.Sprintf("Error (%d):", errNum)), errMsg)
 	if len(helpMsg) > 0 && helpMsg[0] != "" {
 		fmt.Fprintln(os.Stderr, "\n"+helpMsg[0])
 	}
+	writeReport(statusError, errNum, errMsg)
 	os.Exit(errNum)
 }
 
+// handleCheckMode handles the check subcommand and the --check flag.
+// It extracts the current values from every target of every command, or only
+// the command given by --command, and exits with an error if any param or
+// param group holds different values across targets.
+func handleCheckMode(config *repver.RepverConfig) {
+	commands := config.Commands
+	if repver.UserCommand != "" {
+		command, err := config.GetCommand(repver.UserCommand)
+		if err != nil {
+			printErrorAndExit(104, "Command not found", generateHelpMessage(config))
+		}
+		commands = []repver.RepverCommand{*command}
+	}
+
+	var report strings.Builder
+	for _, command := range commands {
+		drifts, err := command.CheckDrift()
+		if err != nil {
+			printErrorAndExit(202, fmt.Sprintf("Failed to read targets for command '%s': %v", command.Name, err))
+		}
+
+		for _, drift := range drifts {
+			report.WriteString(fmt.Sprintf("Command '%s' has different values for '%s':\n", command.Name, drift.Name))
+			for _, observation := range drift.Observations {
+				report.WriteString(fmt.Sprintf("  %s:%d  %s\n", observation.Path, observation.LineNumber, observation.Value))
+			}
+		}
+	}
+
+	if report.Len() > 0 {
+		printErrorAndExit(112, "Drift detected between targets", strings.TrimRight(report.String(), "\n"))
+	}
+
+	fmt.Println(color.Green("No drift detected; all targets agree."))
+}
+
+// getOutput is the JSON document printed by the get subcommand
+type getOutput struct {
+	Command string            `json:"command"`
+	Targets []getTargetOutput `json:"targets"`
+	Params  map[string]string `json:"params"`
+	Drift   []string          `json:"drift,omitempty"`
+}
+
+// getTargetOutput describes the values captured on one line of a target
+type getTargetOutput struct {
+	Path   string            `json:"path"`
+	Line   int               `json:"line"`
+	Values map[string]string `json:"values"`
+}
+
+// handleGetMode handles the get subcommand.
+// It prints the values currently captured by each target of the command in the
+// format selected by --format without modifying anything.
+func handleGetMode(config *repver.RepverConfig) {
+	if repver.UserCommand == "" {
+		printErrorAndExit(103, "No command specified", generateHelpMessage(config))
+	}
+
+	command, err := config.GetCommand(repver.UserCommand)
+	if err != nil {
+		printErrorAndExit(104, "Command not found", generateHelpMessage(config))
+	}
+
+	if repver.Format != "text" && repver.Format != "json" && repver.Format != "env" {
+		printErrorAndExit(113, fmt.Sprintf("Invalid output format '%s' (must be text, json or env)", repver.Format))
+	}
+
+	output := getOutput{
+		Command: command.Name,
+		Targets: []getTargetOutput{},
+		Params:  map[string]string{},
+	}
+	for _, target := range command.Targets {
+		captured, err := target.Capture()
+		if err != nil {
+			printErrorAndExit(202, fmt.Sprintf("Failed to read target '%s': %v", target.Path, err))
+		}
+		for _, line := range captured {
+			output.Targets = append(output.Targets, getTargetOutput{
+				Path:   target.Path,
+				Line:   line.LineNumber,
+				Values: line.Values,
+			})
+		}
+	}
+
+	// Resolve the param values the targets agree on, reversing transforms where possible
+	observations, err := command.Observe()
+	if err != nil {
+		printErrorAndExit(202, fmt.Sprintf("Failed to read targets: %v", err))
+	}
+	for _, observation := range observations {
+		if slices.Contains(output.Drift, observation.Name) {
+			continue
+		}
+		if existing, ok := output.Params[observation.Name]; ok && existing != observation.Value {
+			delete(output.Params, observation.Name)
+			output.Drift = append(output.Drift, observation.Name)
+			continue
+		}
+		output.Params[observation.Name] = observation.Value
+	}
+	sort.Strings(output.Drift)
+
+	switch repver.Format {
+	case "json":
+		data, err := json.MarshalIndent(output, "", "  ")
+		if err != nil {
+			printErrorAndExit(510, "Internal error encoding output")
+		}
+		fmt.Println(string(data))
+	case "env":
+		if len(output.Drift) > 0 {
+			printErrorAndExit(112, "Drift detected between targets", fmt.Sprintf("Targets have different values for: %s\nRun 'repver check --command=%s' for details.", strings.Join(output.Drift, ", "), command.Name))
+		}
+		names := make([]string, 0, len(output.Params))
+		for name := range output.Params {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			fmt.Printf("%s=%s\n", name, repver.ShellQuote(output.Params[name]))
+		}
+	default:
+		for _, target := range output.Targets {
+			groups := make([]string, 0, len(target.Values))
+			for group := range target.Values {
+				groups = append(groups, group)
+			}
+			sort.Strings(groups)
+
+			fmt.Printf("%s:%d", color.Cyan(target.Path), target.Line)
+			for _, group := range groups {
+				fmt.Printf(" %s=%s", group, target.Values[group])
+			}
+			fmt.Println()
+		}
+	}
+}
+
+// handleApplyMode handles the apply subcommand.
+// It loads a plan written by the plan subcommand, refuses to continue if any
+// target file changed since planning, and otherwise writes the planned files
+// and performs the planned git operations.
+func handleApplyMode(client git.Client) {
+	if len(repver.Args) == 0 {
+		printErrorAndExit(118, "No plan file specified", "Usage: repver apply [OPTIONS] <plan file>")
+	}
+	planFile := repver.Args[0]
+
+	// Process: Load plan
+	saved, err := repver.LoadSavedPlan(planFile)
+	if err != nil {
+		printErrorAndExit(120, fmt.Sprintf("Failed to load plan: %v", err))
+	}
+	report.Command = saved.Command
+	report.Params = saved.Params
+	report.Groups = saved.Groups
+
+	// Decision: Targets unchanged since planning?
+	plans, err := saved.ExecutionPlans()
+	if err != nil {
+		printErrorAndExit(121, "Targets changed since the plan was created", fmt.Sprintf("%v\n\nRun 'repver plan' again to create a new plan.", err))
+	}
+	report.Plans = plans
+
+	anyFileModified := false
+	targets := make([]repver.RepverTarget, 0, len(plans))
+	for _, plan := range plans {
+		targets = append(targets, repver.RepverTarget{Path: plan.Path})
+		anyFileModified = anyFileModified || plan.Modified
+	}
+
+	if !anyFileModified {
+		fmt.Println(color.Green("No updates needed; target files already match the requested values."))
+		writeReport(statusNoop, 0, "")
+		return
+	}
+
+	hooks := newHookRunner(&repver.RepverHooks{Timeout: saved.Hooks.Timeout}, saved.Command, saved.Params, saved.Groups)
+	hooks.commands["pre_plan"] = saved.Hooks.PrePlan
+	hooks.commands["post_apply"] = saved.Hooks.PostApply
+	hooks.commands["pre_commit"] = saved.Hooks.PreCommit
+	hooks.commands["verify"] = saved.Hooks.Verify
+	ignorePaths := []string{filepath.ToSlash(filepath.Clean(planFile))}
+	gitOptions := saved.Git.GitOptions()
+
+	// Decision: pre_plan hooks?
+	// They were skipped when the plan was written and run now, before the
+	// changes are applied, so the files they change are committed too
+	if repver.DryRun {
+		hooks.preview("pre_plan")
+	} else if hooks.has("pre_plan") {
+		if err := runPrePlanHooks(client, hooks, &gitOptions, ignorePaths); err != nil {
+			exitWithError(err)
+		}
+	}
+
+	runPlans(client, targets, plans, &gitOptions, runValues{
+		branchName:    saved.Git.Branch,
+		commitMessage: saved.Git.CommitMessage,
+		tagName:       saved.Git.TagName,
+		tagMessage:    saved.Git.TagMessage,
+		ignorePaths:   ignorePaths,
+	}, hooks, nil)
+}
+
 // handleExistsMode handles the --exists flag behavior.
 // It checks if .repver exists and contains the specified command.
 // Exits with 0 if successful, 1 otherwise.
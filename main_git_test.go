@@ -0,0 +1,836 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"testing"
+
+	"github.com/UnitVectorY-Labs/repver/internal/git"
+	"github.com/UnitVectorY-Labs/repver/internal/repver"
+)
+
+// planVersionChange plans a version change in a temp file for executePlans
+func planVersionChange(t *testing.T) ([]repver.RepverTarget, []*repver.ExecutionPlan) {
+	t.Helper()
+	targetPath := filepath.Join(t.TempDir(), "version.txt")
+	if err := os.WriteFile(targetPath, []byte("version: 1.2.3\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	targets := []repver.RepverTarget{{Path: targetPath, Pattern: `^version: (?P<version>.*)$`}}
+	plan, err := targets[0].Plan(map[string]string{"version": "1.3.0"}, nil)
+	if err != nil {
+		t.Fatalf("Plan returned error: %v", err)
+	}
+	return targets, []*repver.ExecutionPlan{plan}
+}
+
+func TestExecutePlansGitFlow(t *testing.T) {
+	repver.DryRun = false
+	targets, plans := planVersionChange(t)
+	client := git.NewFakeClient()
+	gitOptions := &repver.RepverGit{
+		CreateBranch:           true,
+		BranchName:             "release-{{version}}",
+		Commit:                 true,
+		CommitMessage:          "Update version to {{version}}",
+		Push:                   true,
+		PullRequest:            "GITHUB_CLI",
+		ReturnToOriginalBranch: true,
+		DeleteBranch:           true,
+	}
+
+	err := executePlans(context.Background(), client, targets, plans, gitOptions, runValues{branchName: "release-1.3.0", commitMessage: "Update version to 1.3.0"}, nil, nil)
+	if err != nil {
+		t.Fatalf("executePlans returned error: %v", err)
+	}
+
+	expectedCalls := []string{
+		"IsGitRoot", "CheckGitClean", "ValidRefName", "GetCurrentBranch", "BranchExists", "CreateAndSwitchBranch",
+		"AddAndCommitFiles", "GetHeadSHA", "PushChanges", "CreateGitHubPullRequest",
+		"SwitchToBranch", "DeleteLocalBranch",
+	}
+	if got := client.CallNames(); !reflect.DeepEqual(got, expectedCalls) {
+		t.Errorf("unexpected calls:\n got: %v\nwant: %v", got, expectedCalls)
+	}
+
+	expectedCommits := []git.FakeCommit{{Branch: "release-1.3.0", Files: []string{targets[0].Path}, Message: "Update version to 1.3.0"}}
+	if !reflect.DeepEqual(client.Commits, expectedCommits) {
+		t.Errorf("unexpected commits: %+v", client.Commits)
+	}
+	if !reflect.DeepEqual(client.Pushes, []string{"origin/release-1.3.0"}) {
+		t.Errorf("unexpected pushes: %v", client.Pushes)
+	}
+	if client.PullRequests != 1 {
+		t.Errorf("expected one pull request, got %d", client.PullRequests)
+	}
+	if client.Branch != "main" || !reflect.DeepEqual(client.BranchNames(), []string{"main"}) {
+		t.Errorf("expected to end on main with the release branch deleted, got %q %v", client.Branch, client.BranchNames())
+	}
+
+	content, err := os.ReadFile(targets[0].Path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(content) != "version: 1.3.0\n" {
+		t.Errorf("expected target to be updated, got %q", content)
+	}
+}
+
+func TestExecutePlansGitErrors(t *testing.T) {
+	failure := errors.New("failure")
+
+	tests := []struct {
+		name         string
+		setup        func(client *git.FakeClient)
+		expectedCode int
+	}{
+		{"not git root", func(c *git.FakeClient) { c.NotGitRoot = true }, 106},
+		{"workspace not clean", func(c *git.FakeClient) { c.Dirty = []string{"other.txt"} }, 107},
+		{"branch already exists", func(c *git.FakeClient) { c.Branches["release-1.3.0"] = true }, 200},
+		{"create branch fails", func(c *git.FakeClient) { c.Errors["CreateAndSwitchBranch"] = failure }, 201},
+		{"git root check fails", func(c *git.FakeClient) { c.Errors["IsGitRoot"] = failure }, 503},
+		{"branch check fails", func(c *git.FakeClient) { c.Errors["BranchExists"] = failure }, 503},
+		{"current branch fails", func(c *git.FakeClient) { c.Errors["GetCurrentBranch"] = failure }, 504},
+		{"commit fails", func(c *git.FakeClient) { c.Errors["AddAndCommitFiles"] = failure }, 505},
+		{"push fails", func(c *git.FakeClient) { c.Errors["PushChanges"] = failure }, 506},
+		{"switch back fails", func(c *git.FakeClient) { c.Errors["SwitchToBranch"] = failure }, 507},
+		{"pull request fails", func(c *git.FakeClient) { c.Errors["CreateGitHubPullRequest"] = failure }, 508},
+		{"delete branch fails", func(c *git.FakeClient) { c.Errors["DeleteLocalBranch"] = failure }, 509},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			repver.DryRun = false
+			targets, plans := planVersionChange(t)
+			client := git.NewFakeClient()
+			tc.setup(client)
+			gitOptions := &repver.RepverGit{
+				CreateBranch:           true,
+				Commit:                 true,
+				Push:                   true,
+				PullRequest:            "GITHUB_CLI",
+				ReturnToOriginalBranch: true,
+				DeleteBranch:           true,
+			}
+
+			err := executePlans(context.Background(), client, targets, plans, gitOptions, runValues{branchName: "release-1.3.0", commitMessage: "Update version to 1.3.0"}, nil, nil)
+			var exitErr *exitError
+			if !errors.As(err, &exitErr) {
+				t.Fatalf("expected an exit error, got %v", err)
+			}
+			if exitErr.code != tc.expectedCode {
+				t.Errorf("expected exit code %d, got %d (%s)", tc.expectedCode, exitErr.code, exitErr.message)
+			}
+			if tc.expectedCode >= 500 && !errors.Is(err, failure) {
+				t.Errorf("expected the underlying git error to be preserved, got %v", err)
+			}
+		})
+	}
+}
+
+func TestExecutePlansDryRunSkipsGit(t *testing.T) {
+	repver.DryRun = true
+	t.Cleanup(func() { repver.DryRun = false })
+
+	targets, plans := planVersionChange(t)
+	client := git.NewFakeClient()
+	gitOptions := &repver.RepverGit{CreateBranch: true, Commit: true, Push: true}
+
+	if err := executePlans(context.Background(), client, targets, plans, gitOptions, runValues{branchName: "release-1.3.0", commitMessage: "Update version to 1.3.0"}, nil, nil); err != nil {
+		t.Fatalf("executePlans returned error: %v", err)
+	}
+
+	if got := client.CallNames(); !reflect.DeepEqual(got, []string{"ValidRefName", "GetCurrentBranch", "BranchExists"}) {
+		t.Errorf("expected only reads of the branch name, current and new branch, got %v", got)
+	}
+	content, err := os.ReadFile(targets[0].Path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(content) != "version: 1.2.3\n" {
+		t.Errorf("expected target to be untouched in dry run mode, got %q", content)
+	}
+}
+
+func TestExecutePlansInterruptCleansUp(t *testing.T) {
+	repver.DryRun = false
+	targets, plans := planVersionChange(t)
+	client := git.NewFakeClient()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	client.OnCall = func(method string) {
+		if method == "AddAndCommitFiles" {
+			cancel()
+		}
+	}
+	gitOptions := &repver.RepverGit{CreateBranch: true, Commit: true, Push: true, Remote: "origin"}
+
+	err := executePlans(ctx, client, targets, plans, gitOptions, runValues{branchName: "release-1.3.0", commitMessage: "Update version to 1.3.0"}, nil, nil)
+	var exitErr *exitError
+	if !errors.As(err, &exitErr) || exitErr.code != 130 {
+		t.Fatalf("expected exit code 130, got %v", err)
+	}
+
+	if len(client.Commits) != 0 || len(client.Pushes) != 0 {
+		t.Errorf("expected nothing to be committed or pushed, got %v %v", client.Commits, client.Pushes)
+	}
+	if !reflect.DeepEqual(client.Restored, []string{targets[0].Path}) {
+		t.Errorf("expected the target to be restored, got %v", client.Restored)
+	}
+	if client.Branch != "main" || !reflect.DeepEqual(client.BranchNames(), []string{"main"}) {
+		t.Errorf("expected to end on main with the new branch deleted, got %q %v", client.Branch, client.BranchNames())
+	}
+}
+
+func TestExecutePlansFailureAfterCommitKeepsBranch(t *testing.T) {
+	repver.DryRun = false
+	targets, plans := planVersionChange(t)
+	client := git.NewFakeClient()
+	client.Errors["PushChanges"] = errors.New("failure")
+	gitOptions := &repver.RepverGit{CreateBranch: true, Commit: true, Push: true, Remote: "origin"}
+
+	err := executePlans(context.Background(), client, targets, plans, gitOptions, runValues{branchName: "release-1.3.0", commitMessage: "Update version to 1.3.0"}, nil, nil)
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+
+	if len(client.Restored) != 0 {
+		t.Errorf("expected committed files not to be restored, got %v", client.Restored)
+	}
+	if client.Branch != "release-1.3.0" || !client.Branches["release-1.3.0"] {
+		t.Errorf("expected to stay on the branch holding the commit, got %q %v", client.Branch, client.BranchNames())
+	}
+}
+
+func TestExecutePlansRestoresFilesWithoutGit(t *testing.T) {
+	repver.DryRun = false
+	targets, plans := planVersionChange(t)
+	other := filepath.Join(t.TempDir(), "missing", "other.txt")
+	targets = append(targets, repver.RepverTarget{Path: other})
+	plans = append(plans, &repver.ExecutionPlan{Path: other, Modified: true, ModifiedContent: "x"})
+
+	err := executePlans(context.Background(), git.NewFakeClient(), targets, plans, &repver.RepverGit{}, runValues{}, nil, nil)
+	var exitErr *exitError
+	if !errors.As(err, &exitErr) || exitErr.code != 202 {
+		t.Fatalf("expected exit code 202, got %v", err)
+	}
+
+	content, err := os.ReadFile(targets[0].Path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(content) != "version: 1.2.3\n" {
+		t.Errorf("expected the written target to be restored, got %q", content)
+	}
+}
+
+func TestExecutePlansStashRefusesDirtyTarget(t *testing.T) {
+	repver.DryRun = false
+	targets, plans := planVersionChange(t)
+	client := git.NewFakeClient()
+	client.Dirty = []string{targets[0].Path}
+	// The target is restored to its committed content when it is stashed
+	client.OnCall = func(method string) {
+		if method == "StashChanges" {
+			if err := os.WriteFile(targets[0].Path, []byte("version: 1.0.0\n"), 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	gitOptions := &repver.RepverGit{CreateBranch: true, Commit: true, DirtyTree: "stash"}
+
+	err := executePlans(context.Background(), client, targets, plans, gitOptions, runValues{branchName: "release-1.3.0", commitMessage: "Update version to 1.3.0"}, nil, nil)
+	var exitErr *exitError
+	if !errors.As(err, &exitErr) || exitErr.code != 204 {
+		t.Fatalf("expected exit code 204, got %v", err)
+	}
+
+	if len(client.Stashes) != 0 || !reflect.DeepEqual(client.Dirty, []string{targets[0].Path}) {
+		t.Errorf("expected the stash to be popped, got stashes %v dirty %v", client.Stashes, client.Dirty)
+	}
+	if len(client.Commits) != 0 || client.Branches["release-1.3.0"] {
+		t.Errorf("expected nothing to be created, got commits %v branches %v", client.Commits, client.BranchNames())
+	}
+}
+
+func TestExecutePlansWorktree(t *testing.T) {
+	repver.DryRun = false
+	t.Chdir(t.TempDir())
+	if err := os.WriteFile("version.txt", []byte("version: 1.2.3\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	targets := []repver.RepverTarget{{Path: "version.txt", Pattern: `^version: (?P<version>.*)$`}}
+	plans := []*repver.ExecutionPlan{{Path: "version.txt", Modified: true, OriginalContent: "version: 1.2.3\n", ModifiedContent: "version: 1.3.0\n"}}
+
+	client := git.NewFakeClient()
+	client.Dirty = []string{"notes.txt"}
+	client.Files = map[string]string{"version.txt": "version: 1.2.3\n"}
+	var worktreeContent string
+	client.OnCall = func(method string) {
+		if method == "AddAndCommitFiles" {
+			content, _ := os.ReadFile(filepath.Join(client.Worktrees[0], "version.txt"))
+			worktreeContent = string(content)
+		}
+	}
+	gitOptions := &repver.RepverGit{CreateBranch: true, Commit: true, ReturnToOriginalBranch: true, DirtyTree: "worktree"}
+
+	err := executePlans(context.Background(), client, targets, plans, gitOptions, runValues{branchName: "release-1.3.0", commitMessage: "Update version to 1.3.0"}, nil, nil)
+	if err != nil {
+		t.Fatalf("executePlans returned error: %v", err)
+	}
+
+	if worktreeContent != "version: 1.3.0\n" {
+		t.Errorf("expected the update to be written in the worktree, got %q", worktreeContent)
+	}
+	if len(client.Commits) != 1 || client.Commits[0].Branch != "release-1.3.0" {
+		t.Errorf("expected a commit on the new branch, got %+v", client.Commits)
+	}
+	for _, call := range client.Calls {
+		if call.Method == "AddAndCommitFiles" && call.Dir == "" {
+			t.Errorf("expected the commit to run in the worktree")
+		}
+	}
+	if client.Branch != "main" || len(client.Worktrees) != 0 {
+		t.Errorf("expected the checkout on main and the worktree removed, got %q %v", client.Branch, client.Worktrees)
+	}
+	if content, _ := os.ReadFile("version.txt"); string(content) != "version: 1.2.3\n" {
+		t.Errorf("expected the checkout to be untouched, got %q", content)
+	}
+}
+
+func TestExecutePlansBaseBranch(t *testing.T) {
+	repver.DryRun = false
+	targets, plans := planVersionChange(t)
+	client := git.NewFakeClient()
+	client.Branch = "feature"
+	client.Branches["feature"] = true
+	client.Behind = 2
+	gitOptions := &repver.RepverGit{
+		CreateBranch: true,
+		BaseBranch:   "main",
+		Fetch:        true,
+		Commit:       true,
+		Push:         true,
+		Remote:       "origin",
+		PullRequest:  "GITHUB_CLI",
+	}
+
+	err := executePlans(context.Background(), client, targets, plans, gitOptions, runValues{branchName: "release-1.3.0", commitMessage: "Update version to 1.3.0"}, nil, nil)
+	if err != nil {
+		t.Fatalf("executePlans returned error: %v", err)
+	}
+
+	if !reflect.DeepEqual(client.Fetches, []string{"origin/main"}) {
+		t.Errorf("expected main to be fetched, got %v", client.Fetches)
+	}
+	for _, call := range client.Calls {
+		if call.Method == "CreateAndSwitchBranch" && !reflect.DeepEqual(call.Args, []string{"release-1.3.0", "main"}) {
+			t.Errorf("expected the branch to be created from main, got %v", call.Args)
+		}
+	}
+	if client.PullRequestBase != "main" {
+		t.Errorf("expected the pull request against main, got %q", client.PullRequestBase)
+	}
+}
+
+func TestExecutePlansBaseBranchErrors(t *testing.T) {
+	tests := []struct {
+		name         string
+		baseBranch   string
+		setup        func(client *git.FakeClient, targetPath string)
+		expectedCode int
+	}{
+		{"local base not found", "develop", func(c *git.FakeClient, _ string) {}, 205},
+		{"remote base not found", "origin/develop", func(c *git.FakeClient, _ string) {}, 205},
+		{"diverged", "main", func(c *git.FakeClient, _ string) { c.Ahead, c.Behind = 1, 3 }, 206},
+		{"remote base ignores divergence", "origin/main", func(c *git.FakeClient, _ string) { c.Ahead, c.Behind = 1, 3 }, 0},
+		{"fetch fails", "main", func(c *git.FakeClient, _ string) { c.Errors["Fetch"] = errors.New("failure") }, 514},
+		{"target differs on base", "main", func(c *git.FakeClient, targetPath string) {
+			c.OnCall = func(method string) {
+				if method == "CreateAndSwitchBranch" {
+					if err := os.WriteFile(targetPath, []byte("version: 1.0.0\n"), 0644); err != nil {
+						t.Fatal(err)
+					}
+				}
+			}
+		}, 207},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			repver.DryRun = false
+			targets, plans := planVersionChange(t)
+			client := git.NewFakeClient()
+			tc.setup(client, targets[0].Path)
+			gitOptions := &repver.RepverGit{CreateBranch: true, BaseBranch: tc.baseBranch, Fetch: true, Commit: true}
+
+			err := executePlans(context.Background(), client, targets, plans, gitOptions, runValues{branchName: "release-1.3.0", commitMessage: "Update version to 1.3.0"}, nil, nil)
+			if tc.expectedCode == 0 {
+				if err != nil {
+					t.Fatalf("executePlans returned error: %v", err)
+				}
+				return
+			}
+			var exitErr *exitError
+			if !errors.As(err, &exitErr) || exitErr.code != tc.expectedCode {
+				t.Fatalf("expected exit code %d, got %v", tc.expectedCode, err)
+			}
+			if client.Branch != "main" || client.Branches["release-1.3.0"] {
+				t.Errorf("expected to end on main without the new branch, got %q %v", client.Branch, client.BranchNames())
+			}
+		})
+	}
+}
+
+func TestExecutePlansExistingBranch(t *testing.T) {
+	tests := []struct {
+		name             string
+		onExistingBranch string
+		setup            func(client *git.FakeClient)
+		expectedBranch   string
+		expectedCalls    []string
+	}{
+		{
+			"reuse", "reuse", func(c *git.FakeClient) {},
+			"release-1.3.0", []string{"SwitchToBranch", "AddAndCommitFiles", "PushChanges"},
+		},
+		{
+			"recreate", "recreate", func(c *git.FakeClient) {},
+			"release-1.3.0", []string{"ResolveRef", "ResetAndSwitchBranch", "AddAndCommitFiles", "PushChanges"},
+		},
+		{
+			"suffix", "suffix", func(c *git.FakeClient) { c.RemoteBranches["origin/release-1.3.0-2"] = true },
+			"release-1.3.0-3", []string{"CreateAndSwitchBranch", "AddAndCommitFiles", "PushChanges"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			repver.DryRun = false
+			targets, plans := planVersionChange(t)
+			client := git.NewFakeClient()
+			client.Branches["release-1.3.0"] = true
+			tc.setup(client)
+			gitOptions := &repver.RepverGit{CreateBranch: true, Commit: true, Push: true, Remote: "origin", OnExistingBranch: tc.onExistingBranch}
+			replan := func(target repver.RepverTarget) (*repver.ExecutionPlan, error) {
+				return target.Plan(map[string]string{"version": "1.3.0"}, nil)
+			}
+
+			err := executePlans(context.Background(), client, targets, plans, gitOptions, runValues{branchName: "release-1.3.0", commitMessage: "Update version to 1.3.0"}, nil, replan)
+			if err != nil {
+				t.Fatalf("executePlans returned error: %v", err)
+			}
+
+			var calls []string
+			for _, name := range client.CallNames() {
+				if slices.Contains([]string{"SwitchToBranch", "ResolveRef", "ResetAndSwitchBranch", "CreateAndSwitchBranch", "AddAndCommitFiles", "PushChanges"}, name) {
+					calls = append(calls, name)
+				}
+			}
+			if !reflect.DeepEqual(calls, tc.expectedCalls) {
+				t.Errorf("unexpected calls:\n got: %v\nwant: %v", calls, tc.expectedCalls)
+			}
+			if len(client.Commits) != 1 || client.Commits[0].Branch != tc.expectedBranch {
+				t.Errorf("expected a commit on %s, got %+v", tc.expectedBranch, client.Commits)
+			}
+			pushes := append(client.Pushes, client.ForcePushes...)
+			if !reflect.DeepEqual(pushes, []string{"origin/" + tc.expectedBranch}) {
+				t.Errorf("expected a push of %s, got %v", tc.expectedBranch, pushes)
+			}
+			if (tc.onExistingBranch == "recreate") != (len(client.ForcePushes) == 1) {
+				t.Errorf("expected a force push only when recreating, got %v", client.ForcePushes)
+			}
+			if report.Git.Branch != tc.expectedBranch {
+				t.Errorf("expected the report to name %s, got %s", tc.expectedBranch, report.Git.Branch)
+			}
+		})
+	}
+}
+
+func TestExecutePlansReuseReplansOnBranch(t *testing.T) {
+	repver.DryRun = false
+	targets, plans := planVersionChange(t)
+	client := git.NewFakeClient()
+	client.Branches["release-1.3.0"] = true
+	client.OpenPullRequests = map[string]string{"release-1.3.0": "https://github.com/example/repo/pull/7"}
+	// The branch holds a previous update of the target and another change
+	client.OnCall = func(method string) {
+		if method == "SwitchToBranch" {
+			if err := os.WriteFile(targets[0].Path, []byte("# updated\nversion: 1.2.9\n"), 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	gitOptions := &repver.RepverGit{CreateBranch: true, Commit: true, Push: true, Remote: "origin", PullRequest: "GITHUB_CLI", OnExistingBranch: "reuse"}
+	replan := func(target repver.RepverTarget) (*repver.ExecutionPlan, error) {
+		return target.Plan(map[string]string{"version": "1.3.0"}, nil)
+	}
+
+	err := executePlans(context.Background(), client, targets, plans, gitOptions, runValues{branchName: "release-1.3.0", commitMessage: "Update version to 1.3.0"}, nil, replan)
+	if err != nil {
+		t.Fatalf("executePlans returned error: %v", err)
+	}
+
+	content, err := os.ReadFile(targets[0].Path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(content) != "# updated\nversion: 1.3.0\n" {
+		t.Errorf("expected the update to keep the changes on the branch, got %q", content)
+	}
+	if client.PullRequests != 0 || report.Git.PullRequestURL != "https://github.com/example/repo/pull/7" {
+		t.Errorf("expected the open pull request to be reused, got %d created and %q", client.PullRequests, report.Git.PullRequestURL)
+	}
+}
+
+func TestExecutePlansExistingBranchErrors(t *testing.T) {
+	t.Run("fail", func(t *testing.T) {
+		repver.DryRun = false
+		targets, plans := planVersionChange(t)
+		client := git.NewFakeClient()
+		client.Branches["release-1.3.0"] = true
+		gitOptions := &repver.RepverGit{CreateBranch: true, Commit: true, OnExistingBranch: "fail"}
+
+		err := executePlans(context.Background(), client, targets, plans, gitOptions, runValues{branchName: "release-1.3.0", commitMessage: "Update version to 1.3.0"}, nil, nil)
+		var exitErr *exitError
+		if !errors.As(err, &exitErr) || exitErr.code != 200 {
+			t.Fatalf("expected exit code 200, got %v", err)
+		}
+	})
+
+	t.Run("reuse without replan", func(t *testing.T) {
+		repver.DryRun = false
+		targets, plans := planVersionChange(t)
+		client := git.NewFakeClient()
+		client.Branches["release-1.3.0"] = true
+		client.OnCall = func(method string) {
+			if method == "SwitchToBranch" && client.Branch == "main" {
+				if err := os.WriteFile(targets[0].Path, []byte("version: 1.2.9\n"), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+		}
+		gitOptions := &repver.RepverGit{CreateBranch: true, Commit: true, OnExistingBranch: "reuse"}
+
+		err := executePlans(context.Background(), client, targets, plans, gitOptions, runValues{branchName: "release-1.3.0", commitMessage: "Update version to 1.3.0"}, nil, nil)
+		var exitErr *exitError
+		if !errors.As(err, &exitErr) || exitErr.code != 209 {
+			t.Fatalf("expected exit code 209, got %v", err)
+		}
+		if client.Branch != "main" || !client.Branches["release-1.3.0"] {
+			t.Errorf("expected to switch back to main and keep the existing branch, got %q %v", client.Branch, client.BranchNames())
+		}
+	})
+
+	t.Run("recreate is reset on failure", func(t *testing.T) {
+		repver.DryRun = false
+		targets, plans := planVersionChange(t)
+		client := git.NewFakeClient()
+		client.Branches["release-1.3.0"] = true
+		client.Errors["AddAndCommitFiles"] = errors.New("failure")
+		gitOptions := &repver.RepverGit{CreateBranch: true, Commit: true, OnExistingBranch: "recreate"}
+
+		err := executePlans(context.Background(), client, targets, plans, gitOptions, runValues{branchName: "release-1.3.0", commitMessage: "Update version to 1.3.0"}, nil, nil)
+		var exitErr *exitError
+		if !errors.As(err, &exitErr) || exitErr.code != 505 {
+			t.Fatalf("expected exit code 505, got %v", err)
+		}
+		if !reflect.DeepEqual(client.Resets, []string{"release-1.3.0@" + client.HeadSHA}) {
+			t.Errorf("expected the branch to be reset to where it was, got %v", client.Resets)
+		}
+		if client.Branch != "main" || !client.Branches["release-1.3.0"] {
+			t.Errorf("expected to switch back to main and keep the branch, got %q %v", client.Branch, client.BranchNames())
+		}
+	})
+}
+
+func TestExecutePlansDryRunExistingBranch(t *testing.T) {
+	repver.DryRun = true
+	t.Cleanup(func() { repver.DryRun = false })
+
+	for _, policy := range []string{"reuse", "recreate", "suffix"} {
+		t.Run(policy, func(t *testing.T) {
+			targets, plans := planVersionChange(t)
+			client := git.NewFakeClient()
+			client.Branches["release-1.3.0"] = true
+			gitOptions := &repver.RepverGit{CreateBranch: true, Commit: true, OnExistingBranch: policy}
+
+			if err := executePlans(context.Background(), client, targets, plans, gitOptions, runValues{branchName: "release-1.3.0", commitMessage: "Update version to 1.3.0"}, nil, nil); err != nil {
+				t.Fatalf("executePlans returned error: %v", err)
+			}
+
+			expected := map[string]gitStep{
+				"reuse":    {Action: "reuse_branch", Detail: "release-1.3.0"},
+				"recreate": {Action: "recreate_branch", Detail: "release-1.3.0"},
+				"suffix":   {Action: "create_branch", Detail: "release-1.3.0-2"},
+			}[policy]
+			if len(report.Git.Steps) == 0 || report.Git.Steps[len(report.Git.Steps)-2] != expected {
+				t.Errorf("expected step %+v, got %+v", expected, report.Git.Steps)
+			}
+			if len(client.Commits) != 0 || len(client.BranchNames()) != 2 {
+				t.Errorf("expected no changes in dry run mode, got %v %v", client.Commits, client.BranchNames())
+			}
+		})
+	}
+}
+
+func TestExecutePlansTag(t *testing.T) {
+	repver.DryRun = false
+	targets, plans := planVersionChange(t)
+	client := git.NewFakeClient()
+	gitOptions := &repver.RepverGit{
+		Commit:   true,
+		Push:     true,
+		Remote:   "origin",
+		Tag:      true,
+		TagName:  "v{{version}}",
+		TagSign:  "ssh",
+		PushTags: true,
+	}
+
+	err := executePlans(context.Background(), client, targets, plans, gitOptions, runValues{commitMessage: "Update version to 1.3.0", tagName: "v1.3.0", tagMessage: "Release 1.3.0"}, nil, nil)
+	if err != nil {
+		t.Fatalf("executePlans returned error: %v", err)
+	}
+
+	expectedCalls := []string{
+		"IsGitRoot", "CheckGitClean", "ValidRefName", "RefExists", "RemoteTagExists", "GetCurrentBranch",
+		"AddAndCommitFiles", "GetHeadSHA", "CreateTag", "PushChanges", "PushTag",
+	}
+	if got := client.CallNames(); !reflect.DeepEqual(got, expectedCalls) {
+		t.Errorf("unexpected calls:\n got: %v\nwant: %v", got, expectedCalls)
+	}
+	if got := client.Calls[8].Args; !reflect.DeepEqual(got, []string{"v1.3.0", "Release 1.3.0", "true", "ssh"}) {
+		t.Errorf("unexpected CreateTag arguments: %v", got)
+	}
+	if client.Tags["v1.3.0"] != "Release 1.3.0" || !reflect.DeepEqual(client.TagPushes, []string{"origin/v1.3.0"}) {
+		t.Errorf("expected the tag to be created and pushed, got %v %v", client.Tags, client.TagPushes)
+	}
+	if report.Git.Tag != "v1.3.0" {
+		t.Errorf("expected the report to name the tag, got %q", report.Git.Tag)
+	}
+}
+
+func TestExecutePlansTagErrors(t *testing.T) {
+	tests := []struct {
+		name         string
+		setup        func(client *git.FakeClient)
+		expectedCode int
+		expectedTags map[string]string
+	}{
+		{
+			"exists locally",
+			func(c *git.FakeClient) { c.Tags = map[string]string{"v1.3.0": "Earlier release"} },
+			210, map[string]string{"v1.3.0": "Earlier release"},
+		},
+		{
+			"exists on remote",
+			func(c *git.FakeClient) { c.RemoteTags = map[string]bool{"origin/v1.3.0": true} },
+			210, nil,
+		},
+		{
+			"push fails",
+			func(c *git.FakeClient) { c.Errors["PushTag"] = errors.New("rejected") },
+			516, map[string]string{},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			repver.DryRun = false
+			targets, plans := planVersionChange(t)
+			client := git.NewFakeClient()
+			tc.setup(client)
+			gitOptions := &repver.RepverGit{
+				CreateBranch: true,
+				Commit:       true,
+				Remote:       "origin",
+				Tag:          true,
+				TagName:      "v{{version}}",
+				PushTags:     true,
+			}
+
+			err := executePlans(context.Background(), client, targets, plans, gitOptions, runValues{branchName: "release-1.3.0", commitMessage: "Update version to 1.3.0", tagName: "v1.3.0", tagMessage: "v1.3.0"}, nil, nil)
+			var exitErr *exitError
+			if !errors.As(err, &exitErr) || exitErr.code != tc.expectedCode {
+				t.Fatalf("expected exit code %d, got %v", tc.expectedCode, err)
+			}
+			if !reflect.DeepEqual(client.Tags, tc.expectedTags) {
+				t.Errorf("unexpected tags: got %v, want %v", client.Tags, tc.expectedTags)
+			}
+			if tc.expectedCode == 210 && (len(client.Commits) != 0 || len(client.BranchNames()) != 1) {
+				t.Errorf("expected no changes before the tag check, got %v %v", client.Commits, client.BranchNames())
+			}
+		})
+	}
+}
+
+func TestExecutePlansDryRunTag(t *testing.T) {
+	repver.DryRun = true
+	t.Cleanup(func() { repver.DryRun = false })
+	targets, plans := planVersionChange(t)
+	client := git.NewFakeClient()
+	gitOptions := &repver.RepverGit{Commit: true, Remote: "origin", Tag: true, TagName: "v{{version}}", PushTags: true}
+
+	if err := executePlans(context.Background(), client, targets, plans, gitOptions, runValues{commitMessage: "Update version to 1.3.0", tagName: "v1.3.0", tagMessage: "v1.3.0"}, nil, nil); err != nil {
+		t.Fatalf("executePlans returned error: %v", err)
+	}
+
+	steps := report.Git.Steps[len(report.Git.Steps)-2:]
+	expected := []gitStep{{Action: "tag", Detail: "v1.3.0"}, {Action: "push_tag", Detail: "origin/v1.3.0"}}
+	if !reflect.DeepEqual(steps, expected) {
+		t.Errorf("unexpected steps:\n got: %+v\nwant: %+v", steps, expected)
+	}
+	if len(client.Tags) != 0 || len(client.TagPushes) != 0 {
+		t.Errorf("expected no tag in dry run mode, got %v %v", client.Tags, client.TagPushes)
+	}
+}
+
+// hookRunnerFor returns a hookRunner with the commands of each stage
+func hookRunnerFor(commands map[string][]string) *hookRunner {
+	hooks := newHookRunner(&repver.RepverHooks{}, "test", map[string]string{"version": "1.3.0"}, nil)
+	hooks.commands = commands
+	return hooks
+}
+
+func TestExecutePlansHooks(t *testing.T) {
+	repver.DryRun = false
+	t.Chdir(t.TempDir())
+	targets, plans := planVersionChange(t)
+	client := git.NewFakeClient()
+	client.OnCall = func(method string) {
+		if method == "ChangedFiles" && len(client.Dirty) == 0 {
+			client.Dirty = []string{"generated.txt"}
+			client.Untracked = []string{"generated.txt"}
+		}
+	}
+	hooks := hookRunnerFor(map[string][]string{
+		"post_apply": {`echo "$REPVER_VERSION" > generated.txt`},
+		"pre_commit": {"true"},
+	})
+	gitOptions := &repver.RepverGit{CreateBranch: true, Commit: true}
+
+	err := executePlans(context.Background(), client, targets, plans, gitOptions, runValues{branchName: "release-1.3.0", commitMessage: "Update version to 1.3.0"}, hooks, nil)
+	if err != nil {
+		t.Fatalf("executePlans returned error: %v", err)
+	}
+
+	if content, _ := os.ReadFile("generated.txt"); string(content) != "1.3.0\n" {
+		t.Errorf("expected the hook to run with the params, got %q", content)
+	}
+	if len(client.Commits) != 1 || !reflect.DeepEqual(client.Commits[0].Files, []string{targets[0].Path, "generated.txt"}) {
+		t.Errorf("expected the file changed by the hook to be committed, got %+v", client.Commits)
+	}
+}
+
+func TestExecutePlansHookFailureRollsBack(t *testing.T) {
+	repver.DryRun = false
+	t.Chdir(t.TempDir())
+	targets, plans := planVersionChange(t)
+	client := git.NewFakeClient()
+	client.OnCall = func(method string) {
+		if method == "ChangedFiles" {
+			client.Dirty = []string{"generated.txt", "go.sum"}
+			client.Untracked = []string{"generated.txt"}
+		}
+	}
+	hooks := hookRunnerFor(map[string][]string{
+		"post_apply": {"touch generated.txt"},
+		"pre_commit": {"echo checking; exit 3"},
+	})
+	gitOptions := &repver.RepverGit{CreateBranch: true, Commit: true}
+
+	err := executePlans(context.Background(), client, targets, plans, gitOptions, runValues{branchName: "release-1.3.0", commitMessage: "Update version to 1.3.0"}, hooks, nil)
+	var exitErr *exitError
+	if !errors.As(err, &exitErr) || exitErr.code != 211 {
+		t.Fatalf("expected exit code 211, got %v", err)
+	}
+	if exitErr.help != "checking" {
+		t.Errorf("expected the hook output in the error, got %q", exitErr.help)
+	}
+
+	if _, err := os.Stat("generated.txt"); !os.IsNotExist(err) {
+		t.Errorf("expected the new file to be removed, got %v", err)
+	}
+	if !reflect.DeepEqual(client.Restored, []string{targets[0].Path, "go.sum"}) {
+		t.Errorf("expected the target and the tracked file to be restored, got %v", client.Restored)
+	}
+	if len(client.Commits) != 0 || client.Branch != "main" || client.Branches["release-1.3.0"] {
+		t.Errorf("expected no commit and the branch to be removed, got %v %q %v", client.Commits, client.Branch, client.BranchNames())
+	}
+}
+
+func TestExecutePlansVerify(t *testing.T) {
+	repver.DryRun = false
+	t.Chdir(t.TempDir())
+	targets, plans := planVersionChange(t)
+	client := git.NewFakeClient()
+	hooks := hookRunnerFor(map[string][]string{
+		"pre_commit": {"echo generated > generated.txt"},
+		"verify":     {"grep -q generated generated.txt", "grep -q 1.3.0 " + targets[0].Path},
+	})
+	gitOptions := &repver.RepverGit{CreateBranch: true, Commit: true}
+
+	if err := executePlans(context.Background(), client, targets, plans, gitOptions, runValues{branchName: "release-1.3.0", commitMessage: "Update version to 1.3.0"}, hooks, nil); err != nil {
+		t.Fatalf("executePlans failed: %v", err)
+	}
+	if len(client.Commits) != 1 {
+		t.Errorf("expected the verified changes to be committed, got %v", client.Commits)
+	}
+}
+
+func TestExecutePlansVerifyFailureRollsBack(t *testing.T) {
+	repver.DryRun = false
+	t.Chdir(t.TempDir())
+	targets, plans := planVersionChange(t)
+	client := git.NewFakeClient()
+	hooks := hookRunnerFor(map[string][]string{
+		"verify": {"true", "echo FAIL: TestVersion; exit 1", "echo not reached"},
+	})
+	gitOptions := &repver.RepverGit{CreateBranch: true, Commit: true, Push: true, Remote: "origin"}
+
+	err := executePlans(context.Background(), client, targets, plans, gitOptions, runValues{branchName: "release-1.3.0", commitMessage: "Update version to 1.3.0"}, hooks, nil)
+	var exitErr *exitError
+	if !errors.As(err, &exitErr) || exitErr.code != 212 {
+		t.Fatalf("expected exit code 212, got %v", err)
+	}
+	if exitErr.help != "FAIL: TestVersion" {
+		t.Errorf("expected the command output in the error, got %q", exitErr.help)
+	}
+
+	if !reflect.DeepEqual(client.Restored, []string{targets[0].Path}) {
+		t.Errorf("expected the target to be restored, got %v", client.Restored)
+	}
+	if len(client.Commits) != 0 || len(client.Pushes) != 0 || client.Branch != "main" || client.Branches["release-1.3.0"] {
+		t.Errorf("expected no commit or push and the branch to be removed, got %v %v %q %v", client.Commits, client.Pushes, client.Branch, client.BranchNames())
+	}
+}
+
+func TestExecutePlansInvalidRefName(t *testing.T) {
+	repver.DryRun = false
+	targets, plans := planVersionChange(t)
+	client := git.NewFakeClient()
+	gitOptions := &repver.RepverGit{CreateBranch: true, Commit: true}
+
+	// Previous values that differ between targets are listed with ", "
+	err := executePlans(context.Background(), client, targets, plans, gitOptions, runValues{branchName: "repver/1.2, 1.3", commitMessage: "Update version to 1.3.0"}, nil, nil)
+	var exitErr *exitError
+	if !errors.As(err, &exitErr) || exitErr.code != 213 {
+		t.Fatalf("expected exit code 213, got %v", err)
+	}
+	if slices.Contains(client.CallNames(), "CreateAndSwitchBranch") {
+		t.Errorf("expected no branch to be created, got %v", client.CallNames())
+	}
+	content, err := os.ReadFile(targets[0].Path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(content) != "version: 1.2.3\n" {
+		t.Errorf("expected the target to be untouched, got %q", content)
+	}
+}
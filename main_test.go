@@ -5,7 +5,10 @@ import (
 	"os/exec"
 	"regexp"
 	"runtime"
+	"strings"
 	"testing"
+
+	"github.com/UnitVectorY-Labs/repver/internal/repver"
 )
 
 func TestBuildVersionOutputAddsVPrefixAndMetadata(t *testing.T) {
@@ -50,3 +53,12 @@ func TestVersionFlagPrintsStandardizedOutput(t *testing.T) {
 		t.Fatalf("unexpected --version output: got %q", output)
 	}
 }
+
+func TestGenerateHelpMessageListsSubcommands(t *testing.T) {
+	help := generateHelpMessage(&repver.RepverConfig{})
+	for _, name := range []string{"sync", "plan", "apply", "check", "get", "help"} {
+		if !strings.Contains(help, "  repver "+name+" ") {
+			t.Errorf("expected the usage of '%s' in the help, got:\n%s", name, help)
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/UnitVectorY-Labs/repver/internal/git"
+	"github.com/UnitVectorY-Labs/repver/internal/repver"
+)
+
+// Status values reported in the run report
+const (
+	statusSuccess = "success"
+	statusNoop    = "noop"
+	statusError   = "error"
+)
+
+// runReport is the document printed by --output=json describing a full run
+type runReport struct {
+	Command   string                  `json:"command"`
+	DryRun    bool                    `json:"dry_run"`
+	Params    map[string]string       `json:"params"`
+	Groups    map[string]string       `json:"groups"`
+	Plans     []*repver.ExecutionPlan `json:"plans"`
+	PatchOut  string                  `json:"patch_out,omitempty"`
+	Git       gitReport               `json:"git"`
+	Status    string                  `json:"status"`
+	ErrorCode int                     `json:"error_code"`
+	Error     string                  `json:"error,omitempty"`
+	GitError  *git.GitError           `json:"git_error,omitempty"`
+}
+
+// gitReport describes the git steps taken, or planned in dry run mode
+type gitReport struct {
+	OriginalBranch string    `json:"original_branch,omitempty"`
+	Branch         string    `json:"branch,omitempty"`
+	CommitMessage  string    `json:"commit_message,omitempty"`
+	CommitAuthor   string    `json:"commit_author,omitempty"`
+	CommitSign     string    `json:"commit_sign,omitempty"`
+	CommitSignoff  bool      `json:"commit_signoff,omitempty"`
+	CommitSHA      string    `json:"commit_sha,omitempty"`
+	Tag            string    `json:"tag,omitempty"`
+	PushRemote     string    `json:"push_remote,omitempty"`
+	PushBranch     string    `json:"push_branch,omitempty"`
+	PullRequestURL string    `json:"pull_request_url,omitempty"`
+	Steps          []gitStep `json:"steps"`
+}
+
+// gitStep records a single git operation and whether it was performed
+type gitStep struct {
+	Action    string `json:"action"`
+	Detail    string `json:"detail,omitempty"`
+	Performed bool   `json:"performed"`
+}
+
+// report collects the outcome of the run for --output=json
+var report = &runReport{
+	Params: map[string]string{},
+	Groups: map[string]string{},
+	Plans:  []*repver.ExecutionPlan{},
+	Git:    gitReport{Steps: []gitStep{}},
+}
+
+// reportOutput is the stream the run report is written to. It is only set
+// when --output=json is used, in which case os.Stdout is redirected to stderr
+// so the human-readable output does not mix with the document.
+var reportOutput *os.File
+
+// enableJSONOutput switches the run to JSON output. It is safe to call more than once.
+func enableJSONOutput() {
+	if reportOutput != nil {
+		return
+	}
+	reportOutput = os.Stdout
+	os.Stdout = os.Stderr
+}
+
+// disableJSONOutput restores normal output for modes that do not produce a run report
+func disableJSONOutput() {
+	if reportOutput == nil {
+		return
+	}
+	os.Stdout = reportOutput
+	reportOutput = nil
+}
+
+// addGitStep records a git operation in the run report
+func addGitStep(action string, detail string, performed bool) {
+	report.Git.Steps = append(report.Git.Steps, gitStep{
+		Action:    action,
+		Detail:    detail,
+		Performed: performed,
+	})
+}
+
+// writeReport finishes the run report with the given status and prints it
+// when --output=json is used
+func writeReport(status string, errorCode int, errMsg string) {
+	if reportOutput == nil {
+		return
+	}
+
+	report.DryRun = repver.DryRun
+	report.Status = status
+	report.ErrorCode = errorCode
+	report.Error = errMsg
+
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error (510): Internal error encoding output: %v\n", err)
+		os.Exit(510)
+	}
+	fmt.Fprintln(reportOutput, string(data))
+}